@@ -10,6 +10,7 @@ import (
 	"github.com/xhy/api-pulse/config"
 	"github.com/xhy/api-pulse/internal/apifox"
 	"github.com/xhy/api-pulse/internal/dingtalk"
+	"github.com/xhy/api-pulse/internal/metrics"
 	"github.com/xhy/api-pulse/internal/server"
 	"github.com/xhy/api-pulse/internal/service"
 	"github.com/xhy/api-pulse/internal/storage"
@@ -29,49 +30,92 @@ func main() {
 
 	// 初始化API存储 - 纯内存实现
 	apiStore := storage.NewApiStore(logger)
+	metrics.RegisterStoreSize(apiStore.Count)
 
-	// 初始化Apifox客户端
-	apifoxClient := apifox.NewClient(&cfg.Apifox, logger)
+	// 初始化Apifox客户端，按 APIFOX_MODE 选择网页端接口或官方 Open API
+	apifoxClient, err := apifox.NewSource(&cfg.Apifox, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("创建 Apifox 客户端失败")
+	}
+	logger.WithField("mode", cfg.Apifox.Mode).Info("Apifox 客户端已创建")
 
 	// 初始化差异比较服务
 	diffService := apifox.NewDiffService(logger)
+	diffService.SetIgnoreRules(cfg.Diff.IgnoreRules)
 
 	// 初始化钉钉通知服务 - 不再使用 secret
 	notifyService := dingtalk.NewNotifyService(cfg.Dingtalk.WebhookURL, logger)
 
+	// 凭证失效时发送一次告警，凭证恢复后再次失效会重新告警
+	apifoxClient.OnAuthFailure(func(state apifox.AuthState) {
+		if err := notifyService.SendAuthFailureNotification(state); err != nil {
+			logger.WithError(err).Error("发送 Apifox 凭证失效告警失败")
+		}
+	})
+
 	// 初始化API服务
 	apiService := service.NewApiService(logger, apifoxClient, apiStore, diffService)
 
-	// 初始化API列表
-	logger.Info("正在初始化 API 列表...")
-	successCount, failureCount, failedApis, err := apiService.InitializeApiList()
-	if err != nil {
-		logger.WithError(err).Error("初始化 API 列表失败")
-	} else {
-		if len(failedApis) > 0 {
-			logger.WithFields(map[string]interface{}{
-				"failed_apis": failedApis,
-			}).Warn("部分 API 初始化失败")
-		}
+	// 后台任务的根 context，关闭时取消以中止进行中的 Apifox 请求
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-		logger.WithFields(map[string]interface{}{
-			"success_count": successCount,
-			"failure_count": failureCount,
-		}).Info("API 列表初始化完成")
-	}
+	// 初始化API处理器
+	apiHandler := server.NewApiNotifyHandler(apifoxClient, diffService, notifyService, apiStore, logger, apiService)
 
-	// 设置同步间隔为30分钟
-	apiService.SetSyncInterval(30 * time.Minute)
+	// 初始化API目录查询处理器
+	queryHandler := server.NewApiQueryHandler(apiStore, diffService, logger)
 
-	// 启动定时同步任务
-	apiService.StartSync()
-	logger.Info("API定时同步任务已启动")
+	// 初始化管理接口处理器
+	adminHandler := server.NewAdminHandler(apiService, apifoxClient, service.NewJobManager(ctx, logger), cfg.Server.AdminToken, logger)
 
-	// 初始化API处理器
-	apiHandler := server.NewApiNotifyHandler(apifoxClient, diffService, notifyService, apiStore, logger, apiService)
+	// 初始化变更摘要服务，摘要可发送到单独的钉钉机器人
+	digestService, err := service.NewDigestService(logger, apiStore, dingtalk.NewNotifyService(cfg.Digest.WebhookURL, logger),
+		cfg.Digest.Schedule, cfg.Apifox.ProjectID, cfg.Digest.ResponsibleID, cfg.Digest.SendEmpty)
+	if err != nil {
+		logger.WithError(err).Fatal("DIGEST_SCHEDULE 无效")
+	}
+	digestService.Start(ctx)
+
+	// 初始化变更摘要处理器
+	digestHandler := server.NewDigestHandler(digestService, logger)
 
 	// 初始化HTTP服务器
-	srv := server.NewServer(cfg.Server.Port, apiHandler, logger)
+	srv := server.NewServer(cfg.Server.Port, apiHandler, queryHandler, adminHandler, digestHandler, logger)
+
+	// 设置同步间隔，默认每30分钟增量同步、每6小时全量同步
+	apiService.SetSyncInterval(cfg.Sync.Interval)
+	apiService.SetFullSweepInterval(cfg.Sync.FullSweepInterval)
+
+	// 后台初始化API列表，服务先行启动，期间收到的 Webhook 排队等待
+	go func() {
+		logger.Info("正在初始化 API 列表...")
+		successCount, failureCount, failedApis, err := apiService.InitializeApiList(ctx, nil)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.WithError(err).Error("初始化 API 列表失败")
+		} else {
+			if len(failedApis) > 0 {
+				logger.WithFields(map[string]interface{}{
+					"failed_apis": failedApis,
+				}).Warn("部分 API 初始化失败")
+			}
+
+			logger.WithFields(map[string]interface{}{
+				"success_count": successCount,
+				"failure_count": failureCount,
+			}).Info("API 列表初始化完成")
+		}
+
+		// 初始化失败时同样放行排队的 Webhook，避免无限期积压
+		apiHandler.MarkBaselineReady(ctx)
+
+		// 启动定时同步任务
+		apiService.StartSync(ctx)
+		logger.Info("API定时同步任务已启动")
+	}()
 
 	// 处理优雅关闭
 	done := make(chan bool, 1)
@@ -82,13 +126,14 @@ func main() {
 		<-quit
 		logger.Info("服务器正在关闭...")
 
-		// 停止API同步任务
+		// 取消初始化、同步和后台任务，并等待同步任务退出
+		cancel()
 		apiService.StopSync()
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer shutdownCancel()
 
-		if err := srv.Shutdown(ctx); err != nil {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
 			logger.WithError(err).Fatal("强制关闭服务器")
 		}
 
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"strconv"
+	"time"
 )
 
 // Config 应用配置结构
@@ -11,20 +14,87 @@ type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Apifox   ApifoxConfig   `mapstructure:"apifox"`
 	Dingtalk DingtalkConfig `mapstructure:"dingtalk"`
+	Sync     SyncConfig     `mapstructure:"sync"`
+	Diff     DiffConfig     `mapstructure:"diff"`
+	Digest   DigestConfig   `mapstructure:"digest"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port int `mapstructure:"port"`
+	Port       int    `mapstructure:"port"`
+	AdminToken string `mapstructure:"admin_token"` // 为空时不开放管理接口
 }
 
+// Apifox 数据来源模式
+const (
+	ApifoxModeWeb     = "web"     // 模拟网页端调用内部接口
+	ApifoxModeOpenAPI = "openapi" // 使用个人访问令牌调用官方 Open API
+)
+
 // ApifoxConfig Apifox API 配置
 type ApifoxConfig struct {
+	// Mode 数据来源模式，web 或 openapi
+	Mode          string `mapstructure:"mode"`
 	ProjectID     string `mapstructure:"project_id"`
 	BranchID      string `mapstructure:"branch_id"`
 	Authorization string `mapstructure:"authorization"`
 	BaseURL       string `mapstructure:"base_url"`
 	ResponsibleId int    `mapstructure:"responsible_id"`
+
+	// OpenAPIBaseURL 官方 Open API 地址，仅 openapi 模式使用
+	OpenAPIBaseURL string `mapstructure:"openapi_base_url"`
+	// OpenAPIVersion 请求头 X-Apifox-Api-Version 的值
+	OpenAPIVersion string `mapstructure:"openapi_version"`
+
+	// RequestTimeout 单个 Apifox 请求的超时时间
+	RequestTimeout time.Duration `mapstructure:"request_timeout"`
+
+	// RateLimit 所有 Apifox 请求共享的每秒请求数上限，0 表示不限制
+	RateLimit float64 `mapstructure:"rate_limit"`
+	// RateBurst 限流允许的突发请求数
+	RateBurst int `mapstructure:"rate_burst"`
+	// RetryCount GET 请求失败（网络错误、429、5xx）后的最大重试次数
+	RetryCount int `mapstructure:"retry_count"`
+	// RetryWait 首次重试前的等待时间，之后按指数退避并加入随机抖动
+	RetryWait time.Duration `mapstructure:"retry_wait"`
+	// RetryMaxWait 单次重试的最长等待时间，Retry-After 超出时同样以此为上限
+	RetryMaxWait time.Duration `mapstructure:"retry_max_wait"`
+}
+
+// SyncConfig 定时同步配置
+type SyncConfig struct {
+	Interval time.Duration `mapstructure:"interval"`
+	// FullSweepInterval 全量同步间隔，其余同步只获取树形列表元数据有变化的 API
+	FullSweepInterval time.Duration `mapstructure:"full_sweep_interval"`
+}
+
+// DiffConfig 差异比较配置
+type DiffConfig struct {
+	// IgnoreRules 比较时忽略的路径，命中的内容不会出现在差异和通知中
+	IgnoreRules []IgnoreRule `mapstructure:"ignore_rules"`
+}
+
+// IgnoreRule 一条忽略规则
+type IgnoreRule struct {
+	// Path 以 "." 分隔的 API 详情 JSON 路径，"*" 匹配一层，"**" 匹配任意多层；
+	// 不含 "." 的名称匹配任意层级的同名字段，如 "description"
+	Path string `json:"path" mapstructure:"path"`
+	// ApiIDs 仅对这些 API 生效，与 FolderIDs 都为空时对所有 API 生效
+	ApiIDs []int `json:"api_ids,omitempty" mapstructure:"api_ids"`
+	// FolderIDs 仅对直接位于这些目录下的 API 生效
+	FolderIDs []int `json:"folder_ids,omitempty" mapstructure:"folder_ids"`
+}
+
+// DigestConfig 定时变更摘要配置
+type DigestConfig struct {
+	// Schedule cron 表达式（分 时 日 月 周），如每天 9 点 "0 9 * * *"、每周一 9 点 "0 9 * * 1"；为空时不定时发送
+	Schedule string `mapstructure:"schedule"`
+	// ResponsibleID 只汇总该负责人的接口，0 表示整个项目
+	ResponsibleID int `mapstructure:"responsible_id"`
+	// WebhookURL 摘要发送到的钉钉机器人，为空时使用 dingtalk.webhook_url
+	WebhookURL string `mapstructure:"webhook_url"`
+	// SendEmpty 期间没有变更时是否仍然发送
+	SendEmpty bool `mapstructure:"send_empty"`
 }
 
 // DingtalkConfig 钉钉配置
@@ -43,29 +113,79 @@ func LoadConfig(path string) (*Config, error) {
 		port = 9501 // 默认端口
 	}
 	cfg.Server = ServerConfig{
-		Port: port,
+		Port:       port,
+		AdminToken: getEnvOrDefault("ADMIN_TOKEN", ""),
 	}
 
 	// 加载Apifox配置
+	mode := getEnvOrDefault("APIFOX_MODE", ApifoxModeWeb)
 	projectID := getEnvOrDefault("APIFOX_PROJECT_ID", "") // 提供默认值
 	branchID := getEnvOrDefault("APIFOX_BRANCH_ID", "")   // 提供默认值
+	authorization := getEnvOrDefault("APIFOX_AUTHORIZATION", "")
 
 	// 验证必要的配置项
+	if mode != ApifoxModeWeb && mode != ApifoxModeOpenAPI {
+		return nil, fmt.Errorf("APIFOX_MODE 无效: %s，可选值为 %s、%s", mode, ApifoxModeWeb, ApifoxModeOpenAPI)
+	}
 	if projectID == "" {
 		return nil, errors.New("APIFOX_PROJECT_ID 环境变量未设置")
 	}
-	if branchID == "" {
+	// Open API 模式未指定分支时导出主分支
+	if branchID == "" && mode == ApifoxModeWeb {
 		return nil, errors.New("APIFOX_BRANCH_ID 环境变量未设置")
 	}
+	// Open API 模式使用个人访问令牌
+	if mode == ApifoxModeOpenAPI {
+		authorization = getEnvOrDefault("APIFOX_ACCESS_TOKEN", "")
+		if authorization == "" {
+			return nil, errors.New("openapi 模式需要设置 APIFOX_ACCESS_TOKEN 环境变量")
+		}
+	}
 
 	responsibleId, err := strconv.Atoi(getEnvOrDefault("APIFOX_RESPONSIBLE_ID", ""))
 
+	requestTimeout, err := getEnvDuration("APIFOX_REQUEST_TIMEOUT", "30s")
+	if err != nil {
+		return nil, err
+	}
+
+	// 加载 Apifox 限流与重试配置
+	rateLimit, err := strconv.ParseFloat(getEnvOrDefault("APIFOX_RATE_LIMIT", "10"), 64)
+	if err != nil || rateLimit < 0 {
+		return nil, fmt.Errorf("APIFOX_RATE_LIMIT 格式无效: %s", os.Getenv("APIFOX_RATE_LIMIT"))
+	}
+	rateBurst, err := strconv.Atoi(getEnvOrDefault("APIFOX_RATE_BURST", "5"))
+	if err != nil || rateBurst < 1 {
+		return nil, fmt.Errorf("APIFOX_RATE_BURST 格式无效: %s", os.Getenv("APIFOX_RATE_BURST"))
+	}
+	retryCount, err := strconv.Atoi(getEnvOrDefault("APIFOX_RETRY_COUNT", "3"))
+	if err != nil || retryCount < 0 {
+		return nil, fmt.Errorf("APIFOX_RETRY_COUNT 格式无效: %s", os.Getenv("APIFOX_RETRY_COUNT"))
+	}
+	retryWait, err := getEnvDuration("APIFOX_RETRY_WAIT", "500ms")
+	if err != nil {
+		return nil, err
+	}
+	retryMaxWait, err := getEnvDuration("APIFOX_RETRY_MAX_WAIT", "30s")
+	if err != nil {
+		return nil, err
+	}
+
 	cfg.Apifox = ApifoxConfig{
-		ProjectID:     projectID,
-		BranchID:      branchID,
-		Authorization: getEnvOrDefault("APIFOX_AUTHORIZATION", ""),
-		BaseURL:       getEnvOrDefault("APIFOX_BASE_URL", "https://api.apifox.com/api/v1"),
-		ResponsibleId: responsibleId,
+		Mode:           mode,
+		ProjectID:      projectID,
+		BranchID:       branchID,
+		Authorization:  authorization,
+		BaseURL:        getEnvOrDefault("APIFOX_BASE_URL", "https://api.apifox.com/api/v1"),
+		ResponsibleId:  responsibleId,
+		OpenAPIBaseURL: getEnvOrDefault("APIFOX_OPENAPI_BASE_URL", "https://api.apifox.com"),
+		OpenAPIVersion: getEnvOrDefault("APIFOX_API_VERSION", "2024-03-28"),
+		RequestTimeout: requestTimeout,
+		RateLimit:      rateLimit,
+		RateBurst:      rateBurst,
+		RetryCount:     retryCount,
+		RetryWait:      retryWait,
+		RetryMaxWait:   retryMaxWait,
 	}
 
 	// 加载钉钉配置
@@ -73,9 +193,98 @@ func LoadConfig(path string) (*Config, error) {
 		WebhookURL: getEnvOrDefault("DINGTALK_WEBHOOK_URL", ""),
 	}
 
+	// 加载同步配置
+	syncInterval, err := getEnvPositiveDuration("SYNC_INTERVAL", "30m")
+	if err != nil {
+		return nil, err
+	}
+	fullSweepInterval, err := getEnvPositiveDuration("SYNC_FULL_SWEEP_INTERVAL", "6h")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Sync = SyncConfig{
+		Interval:          syncInterval,
+		FullSweepInterval: fullSweepInterval,
+	}
+
+	// 加载差异比较的忽略规则，文件与环境变量中的规则合并生效
+	ignoreRules, err := loadIgnoreRules()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Diff = DiffConfig{
+		IgnoreRules: ignoreRules,
+	}
+
+	// 加载定时变更摘要配置
+	digestResponsibleID, err := strconv.Atoi(getEnvOrDefault("DIGEST_RESPONSIBLE_ID", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("DIGEST_RESPONSIBLE_ID 格式无效: %s", os.Getenv("DIGEST_RESPONSIBLE_ID"))
+	}
+	cfg.Digest = DigestConfig{
+		Schedule:      getEnvOrDefault("DIGEST_SCHEDULE", ""),
+		ResponsibleID: digestResponsibleID,
+		WebhookURL:    getEnvOrDefault("DIGEST_WEBHOOK_URL", cfg.Dingtalk.WebhookURL),
+		SendEmpty:     getEnvOrDefault("DIGEST_SEND_EMPTY", "false") == "true",
+	}
+
 	return cfg, nil
 }
 
+// loadIgnoreRules 从 DIFF_IGNORE_RULES_FILE 指向的文件和 DIFF_IGNORE_RULES 环境变量读取忽略规则，
+// 两者都是 IgnoreRule 的 JSON 数组
+func loadIgnoreRules() ([]IgnoreRule, error) {
+	var rules []IgnoreRule
+
+	if path := getEnvOrDefault("DIFF_IGNORE_RULES_FILE", ""); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取 DIFF_IGNORE_RULES_FILE 失败: %w", err)
+		}
+		var fileRules []IgnoreRule
+		if err := json.Unmarshal(data, &fileRules); err != nil {
+			return nil, fmt.Errorf("DIFF_IGNORE_RULES_FILE 格式无效: %w", err)
+		}
+		rules = append(rules, fileRules...)
+	}
+
+	if value := getEnvOrDefault("DIFF_IGNORE_RULES", ""); value != "" {
+		var envRules []IgnoreRule
+		if err := json.Unmarshal([]byte(value), &envRules); err != nil {
+			return nil, fmt.Errorf("DIFF_IGNORE_RULES 格式无效: %w", err)
+		}
+		rules = append(rules, envRules...)
+	}
+
+	for i, rule := range rules {
+		if rule.Path == "" {
+			return nil, fmt.Errorf("第 %d 条忽略规则缺少 path", i+1)
+		}
+	}
+	return rules, nil
+}
+
+// getEnvDuration 获取时长类型的环境变量，如 "30s"、"500ms"
+func getEnvDuration(key, defaultValue string) (time.Duration, error) {
+	value, err := time.ParseDuration(getEnvOrDefault(key, defaultValue))
+	if err != nil {
+		return 0, fmt.Errorf("%s 格式无效: %w", key, err)
+	}
+	return value, nil
+}
+
+// getEnvPositiveDuration 获取用作定时器间隔的时长，为 0 或负数时 time.NewTicker 会 panic，因此返回错误
+func getEnvPositiveDuration(key, defaultValue string) (time.Duration, error) {
+	value, err := getEnvDuration(key, defaultValue)
+	if err != nil {
+		return 0, err
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("%s 必须大于 0: %s", key, os.Getenv(key))
+	}
+	return value, nil
+}
+
 // getEnvOrDefault 获取环境变量，如果不存在则返回默认值
 func getEnvOrDefault(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -0,0 +1,124 @@
+package apifoxtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/internal/apifox"
+)
+
+func testProject() *Project {
+	return NewProject(
+		apifox.ApiDetail{
+			ID:     1,
+			Name:   "获取用户",
+			Method: "get",
+			Path:   "/users/{id}",
+			Parameters: apifox.Parameters{
+				Path: []apifox.Parameter{{Name: "id", Required: true, Type: "integer"}},
+			},
+			ResponsibleID: 7,
+		},
+		apifox.ApiDetail{ID: 2, Name: "创建用户", Method: "post", Path: "/users", FolderID: 3},
+	)
+}
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// TestClientAgainstServer 真实客户端读取模拟服务的结果应与内存数据来源一致
+func TestClientAgainstServer(t *testing.T) {
+	ctx := context.Background()
+	project := testProject()
+	server := NewServer(project)
+	defer server.Close()
+
+	client := apifox.NewClient(server.Config("token"), testLogger())
+	source := NewSource(project)
+
+	for name, s := range map[string]apifox.Source{"client": client, "source": source} {
+		mappings, err := s.GetApiMappings(ctx)
+		if err != nil {
+			t.Fatalf("%s: GetApiMappings: %v", name, err)
+		}
+		if len(mappings) != 2 || mappings["get /users/{id}"].ResponsibleID != 7 || mappings["post /users"].ID != 2 {
+			t.Errorf("%s: unexpected mappings %+v", name, mappings)
+		}
+
+		resp, err := s.GetApiDetail(ctx, "apiDetail.1")
+		if err != nil {
+			t.Fatalf("%s: GetApiDetail: %v", name, err)
+		}
+		want, _ := project.Get(1)
+		if resp.Data.Path != want.Path || !reflect.DeepEqual(resp.Data.Parameters.Path, want.Parameters.Path) {
+			t.Errorf("%s: detail = %+v, want %+v", name, resp.Data, want)
+		}
+	}
+
+	if got := project.DetailFetches(1); got != 2 {
+		t.Errorf("DetailFetches = %d, want 2", got)
+	}
+}
+
+// TestProjectMutations 修改项目后两种实现都应立即看到新数据
+func TestProjectMutations(t *testing.T) {
+	ctx := context.Background()
+	project := testProject()
+	source := NewSource(project)
+
+	project.Update(1, func(detail *apifox.ApiDetail) { detail.Path = "/members/{id}" })
+	project.Delete(2)
+
+	mappings, err := source.GetApiMappings(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mappings["get /members/{id}"]; !ok || len(mappings) != 1 {
+		t.Errorf("unexpected mappings %+v", mappings)
+	}
+
+	project.FailDetail(1, 1)
+	if _, err := source.GetApiDetail(ctx, "apiDetail.1"); err == nil {
+		t.Error("expected simulated failure")
+	}
+	if _, err := source.GetApiDetail(ctx, "apiDetail.1"); err != nil {
+		t.Errorf("second fetch should succeed: %v", err)
+	}
+}
+
+// TestRequireToken 凭证不符时两种实现都返回 ErrUnauthorized 并更新凭证状态
+func TestRequireToken(t *testing.T) {
+	ctx := context.Background()
+	project := testProject()
+	project.RequireToken("good")
+	server := NewServer(project)
+	defer server.Close()
+
+	client := apifox.NewClient(server.Config("bad"), testLogger())
+	source := NewSource(project)
+	source.SetAuthorization("bad")
+
+	for name, s := range map[string]apifox.Source{"client": client, "source": source} {
+		if _, err := s.GetApiTreeList(ctx); !errors.Is(err, apifox.ErrUnauthorized) {
+			t.Errorf("%s: err = %v, want ErrUnauthorized", name, err)
+		}
+		if state := s.AuthState(); !state.Checked || state.Valid {
+			t.Errorf("%s: state = %+v, want invalid", name, state)
+		}
+		if err := s.VerifyToken(ctx, "good"); err != nil {
+			t.Errorf("%s: VerifyToken(good) = %v", name, err)
+		}
+
+		s.SetAuthorization("good")
+		if _, err := s.GetApiTreeList(ctx); err != nil {
+			t.Errorf("%s: after SetAuthorization: %v", name, err)
+		}
+	}
+}
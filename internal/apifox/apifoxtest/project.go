@@ -0,0 +1,151 @@
+package apifoxtest
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/xhy/api-pulse/internal/apifox"
+)
+
+// Project 内存中的 Apifox 项目，内存数据来源与模拟服务共用，测试步骤之间可随时修改
+type Project struct {
+	mu sync.RWMutex
+
+	apis        map[int]apifox.ApiDetail
+	detailFails map[int]int
+	fetches     map[int]int
+	treeFetches int
+	token       string
+}
+
+// NewProject 创建包含给定 API 的项目
+func NewProject(apis ...apifox.ApiDetail) *Project {
+	p := &Project{
+		apis:        make(map[int]apifox.ApiDetail),
+		detailFails: make(map[int]int),
+		fetches:     make(map[int]int),
+	}
+	for _, detail := range apis {
+		p.apis[detail.ID] = detail
+	}
+	return p
+}
+
+// Put 新增或整体替换一个 API
+func (p *Project) Put(detail apifox.ApiDetail) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.apis[detail.ID] = detail
+}
+
+// Update 修改已有的 API，API 不存在时返回 false
+func (p *Project) Update(id int, fn func(detail *apifox.ApiDetail)) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	detail, ok := p.apis[id]
+	if !ok {
+		return false
+	}
+	fn(&detail)
+	p.apis[id] = detail
+	return true
+}
+
+// Delete 删除一个 API
+func (p *Project) Delete(id int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.apis, id)
+}
+
+// Get 返回 API 的当前详情
+func (p *Project) Get(id int) (apifox.ApiDetail, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	detail, ok := p.apis[id]
+	return detail, ok
+}
+
+// FailDetail 让接下来 times 次获取该 API 详情的请求失败，用于模拟 Apifox 临时故障
+func (p *Project) FailDetail(id int, times int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.detailFails[id] = times
+}
+
+// RequireToken 要求请求携带指定凭证，否则按凭证失效处理；为空表示不校验
+func (p *Project) RequireToken(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.token = token
+}
+
+// DetailFetches 返回获取过该 API 详情的次数，包括失败的请求
+func (p *Project) DetailFetches(id int) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.fetches[id]
+}
+
+// TreeFetches 返回获取树形列表的次数
+func (p *Project) TreeFetches() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.treeFetches
+}
+
+// authorized 校验凭证
+func (p *Project) authorized(token string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.token == "" || p.token == token
+}
+
+// tree 返回当前的树形列表数据
+func (p *Project) tree() *apifox.ApiTree {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.treeFetches++
+	return treeData(p.apis)
+}
+
+// mappings 返回以 "method path" 为键的 API 基本信息，与客户端从树形列表中提取的结果一致
+func (p *Project) mappings() map[string]apifox.ApiBasic {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return treeData(p.apis).Mappings()
+}
+
+// errDetailNotFound API 不存在
+var errDetailNotFound = fmt.Errorf("API 不存在")
+
+// errDetailFailed 模拟的临时故障
+var errDetailFailed = fmt.Errorf("模拟的 Apifox 故障")
+
+// detail 返回 API 详情并记录请求次数，FailDetail 设置的失败次数未用完时返回 errDetailFailed
+func (p *Project) detail(id int) (apifox.ApiDetail, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.fetches[id]++
+	if p.detailFails[id] > 0 {
+		p.detailFails[id]--
+		return apifox.ApiDetail{}, errDetailFailed
+	}
+	detail, ok := p.apis[id]
+	if !ok {
+		return apifox.ApiDetail{}, errDetailNotFound
+	}
+	return detail, nil
+}
@@ -0,0 +1,91 @@
+package apifoxtest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"github.com/xhy/api-pulse/config"
+	"github.com/xhy/api-pulse/internal/apifox"
+)
+
+// Server 模拟 Apifox 网页端接口（树形列表与接口详情）的 HTTP 服务，数据来自 Project
+type Server struct {
+	*httptest.Server
+
+	project *Project
+}
+
+// NewServer 启动读取 project 的模拟服务，使用完毕后需调用 Close
+func NewServer(project *Project) *Server {
+	s := &Server{project: project}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /projects/{projectID}/api-tree-list", s.handleTreeList)
+	mux.HandleFunc("GET /projects/{projectID}/http-apis/{apiID}", s.handleApiDetail)
+	s.Server = httptest.NewServer(s.requireToken(mux))
+	return s
+}
+
+// Config 返回指向模拟服务的网页端模式配置，可直接用于 apifox.NewClient
+func (s *Server) Config(authorization string) *config.ApifoxConfig {
+	return &config.ApifoxConfig{
+		Mode:          config.ApifoxModeWeb,
+		ProjectID:     "1",
+		BranchID:      "1",
+		Authorization: authorization,
+		BaseURL:       s.URL,
+	}
+}
+
+// requireToken 凭证不符合 Project.RequireToken 的要求时返回 401
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("authorization"), "Bearer ")
+		if !s.project.authorized(token) {
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+				"success":      false,
+				"errorMessage": "登录已过期",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleTreeList 返回树形列表
+func (s *Server) handleTreeList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, apifox.ApiTreeListResponse{
+		Success: true,
+		Data:    *s.project.tree(),
+	})
+}
+
+// handleApiDetail 返回接口详情，API 不存在时返回 404，模拟故障时返回 500
+func (s *Server) handleApiDetail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("apiID"))
+	if err != nil {
+		http.Error(w, "无效的 API ID", http.StatusBadRequest)
+		return
+	}
+
+	detail, err := s.project.detail(id)
+	switch {
+	case errors.Is(err, errDetailNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"success": false})
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"success": false})
+	default:
+		writeJSON(w, http.StatusOK, apifox.ApiDetailResponse{Success: true, Data: detail})
+	}
+}
+
+// writeJSON 以 JSON 格式写出响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
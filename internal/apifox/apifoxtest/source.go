@@ -0,0 +1,137 @@
+package apifoxtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xhy/api-pulse/config"
+	"github.com/xhy/api-pulse/internal/apifox"
+)
+
+// Source 直接读取 Project 的 apifox.Source 实现，不经过 HTTP
+type Source struct {
+	project *Project
+	config  *config.ApifoxConfig
+
+	mu            sync.Mutex
+	authorization string
+	authState     apifox.AuthState
+	onAuthFailure func(state apifox.AuthState)
+}
+
+var _ apifox.Source = (*Source)(nil)
+
+// NewSource 创建读取 project 的内存数据来源
+func NewSource(project *Project) *Source {
+	return &Source{
+		project: project,
+		config:  &config.ApifoxConfig{Mode: config.ApifoxModeWeb, ProjectID: "1", BranchID: "1"},
+	}
+}
+
+// GetConfig 返回客户端配置
+func (s *Source) GetConfig() *config.ApifoxConfig {
+	return s.config
+}
+
+// GetApiTreeList 返回项目当前的树形列表
+func (s *Source) GetApiTreeList(ctx context.Context) (*apifox.ApiTreeListResponse, error) {
+	if err := s.check(ctx); err != nil {
+		return nil, err
+	}
+	return &apifox.ApiTreeListResponse{Success: true, Data: *s.project.tree()}, nil
+}
+
+// GetApiMappings 返回以 "method path" 为键的 API 基本信息
+func (s *Source) GetApiMappings(ctx context.Context) (map[string]apifox.ApiBasic, error) {
+	if _, err := s.GetApiTreeList(ctx); err != nil {
+		return nil, err
+	}
+	return s.project.mappings(), nil
+}
+
+// GetApiDetail 返回单个 API 的详情，apiKey 格式为 "apiDetail.ID"
+func (s *Source) GetApiDetail(ctx context.Context, apiKey string) (*apifox.ApiDetailResponse, error) {
+	var id int
+	if _, err := fmt.Sscanf(apiKey, "apiDetail.%d", &id); err != nil {
+		return nil, fmt.Errorf("无效的 API Key 格式: %s", apiKey)
+	}
+	if err := s.check(ctx); err != nil {
+		return nil, err
+	}
+
+	detail, err := s.project.detail(id)
+	if err != nil {
+		return nil, fmt.Errorf("API 详情请求失败: %w", err)
+	}
+	return &apifox.ApiDetailResponse{Success: true, Data: detail}, nil
+}
+
+// check 检查 ctx 是否已取消并校验凭证，凭证状态的变化与真实客户端一致
+func (s *Source) check(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	valid := s.project.authorized(s.authorization)
+	wasInvalid := s.authState.Checked && !s.authState.Valid
+	now := time.Now()
+	s.authState.Checked = true
+	s.authState.Valid = valid
+	s.authState.CheckedAt = now
+	if valid {
+		s.authState.LastStatus = 200
+		s.authState.InvalidSince = time.Time{}
+		return nil
+	}
+
+	s.authState.LastStatus = 401
+	if !wasInvalid {
+		s.authState.InvalidSince = now
+		if s.onAuthFailure != nil {
+			go s.onAuthFailure(s.authState)
+		}
+	}
+	return fmt.Errorf("API 请求失败: %w (HTTP 401)", apifox.ErrUnauthorized)
+}
+
+// AuthState 返回当前的凭证状态
+func (s *Source) AuthState() apifox.AuthState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.authState
+}
+
+// OnAuthFailure 注册凭证失效时的回调
+func (s *Source) OnAuthFailure(fn func(state apifox.AuthState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onAuthFailure = fn
+}
+
+// SetAuthorization 热替换凭证，凭证状态重置为未检查
+func (s *Source) SetAuthorization(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.authorization = token
+	s.authState = apifox.AuthState{}
+}
+
+// VerifyToken 校验候选凭证，不影响当前凭证及其状态
+func (s *Source) VerifyToken(ctx context.Context, token string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !s.project.authorized(token) {
+		return fmt.Errorf("验证 Apifox 凭证失败: %w (HTTP 401)", apifox.ErrUnauthorized)
+	}
+	return nil
+}
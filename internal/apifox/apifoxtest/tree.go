@@ -0,0 +1,79 @@
+// Package apifoxtest 提供测试和本地演示用的 Apifox 替身：
+// 内存实现的 apifox.Source，以及模拟 Apifox 网页端接口的 httptest 服务
+package apifoxtest
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/xhy/api-pulse/internal/apifox"
+)
+
+// apiKey 返回 API 在树形列表中的 key
+func apiKey(id int) string {
+	return fmt.Sprintf("apiDetail.%d", id)
+}
+
+// sortedDetails 按 ID 排序，保证树形列表顺序稳定
+func sortedDetails(apis map[int]apifox.ApiDetail) []apifox.ApiDetail {
+	details := make([]apifox.ApiDetail, 0, len(apis))
+	for _, detail := range apis {
+		details = append(details, detail)
+	}
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].ID < details[j].ID
+	})
+	return details
+}
+
+// treeData 按 Apifox 网页端的格式构建树形列表：FolderID 不为 0 的 API 放在对应目录下
+func treeData(apis map[int]apifox.ApiDetail) *apifox.ApiTree {
+	var roots []*apifox.ApiTreeItem
+	folders := make(map[int]*apifox.ApiTreeItem)
+	var folderIDs []int
+
+	for _, detail := range sortedDetails(apis) {
+		item := &apifox.ApiTreeItem{
+			Key:  apiKey(detail.ID),
+			Type: apifox.TreeTypeApi,
+			Name: detail.Name,
+			Api: &apifox.ApiBasic{
+				ID:            detail.ID,
+				Name:          detail.Name,
+				Type:          "http",
+				Method:        detail.Method,
+				Path:          detail.Path,
+				FolderID:      detail.FolderID,
+				Tags:          detail.Tags,
+				Status:        detail.Status,
+				ResponsibleID: detail.ResponsibleID,
+				EditorID:      detail.EditorID,
+				UpdatedAt:     detail.UpdatedAt,
+			},
+		}
+
+		if detail.FolderID == 0 {
+			roots = append(roots, item)
+			continue
+		}
+		folder, ok := folders[detail.FolderID]
+		if !ok {
+			folder = &apifox.ApiTreeItem{
+				Key:    fmt.Sprintf("apiDetailFolder.%d", detail.FolderID),
+				Type:   apifox.TreeTypeApiFolder,
+				Name:   fmt.Sprintf("目录%d", detail.FolderID),
+				Folder: &apifox.ApiFolder{ID: detail.FolderID, Name: fmt.Sprintf("目录%d", detail.FolderID)},
+			}
+			folders[detail.FolderID] = folder
+			folderIDs = append(folderIDs, detail.FolderID)
+		}
+		folder.Children = append(folder.Children, item)
+	}
+
+	sort.Ints(folderIDs)
+	for _, id := range folderIDs {
+		roots = append(roots, folders[id])
+	}
+
+	return apifox.NewApiTree(roots)
+}
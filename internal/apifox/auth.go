@@ -0,0 +1,119 @@
+package apifox
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrUnauthorized Apifox 返回 401/403，凭证失效或无权限
+var ErrUnauthorized = errors.New("Apifox 凭证失效")
+
+// AuthState 根据最近一次 Apifox 响应推断的凭证状态
+type AuthState struct {
+	Checked      bool      `json:"checked"`
+	Valid        bool      `json:"valid"`
+	LastStatus   int       `json:"last_status"`
+	CheckedAt    time.Time `json:"checked_at"`
+	InvalidSince time.Time `json:"invalid_since,omitempty"`
+}
+
+// verifyTokenKey 标记验证候选凭证的请求，其响应不计入凭证状态
+type verifyTokenKey struct{}
+
+// authTracker 保存当前凭证并根据响应状态码跟踪其有效性，网页端与 Open API 两种客户端共用
+type authTracker struct {
+	logger *logrus.Logger
+
+	authMutex     sync.RWMutex
+	authorization string
+	authState     AuthState
+	onAuthFailure func(state AuthState)
+}
+
+// newAuthTracker 创建凭证状态跟踪器
+func newAuthTracker(authorization string, logger *logrus.Logger) *authTracker {
+	return &authTracker{
+		logger:        logger,
+		authorization: authorization,
+	}
+}
+
+// recordAuthStatus 根据响应状态码更新凭证状态，401/403 视为凭证失效；
+// 从有效（或未知）变为失效时调用一次 OnAuthFailure 注册的回调
+func (t *authTracker) recordAuthStatus(statusCode int) {
+	t.authMutex.Lock()
+	defer t.authMutex.Unlock()
+
+	now := time.Now()
+	wasInvalid := t.authState.Checked && !t.authState.Valid
+
+	switch {
+	case isAuthFailure(statusCode):
+		t.authState.Valid = false
+		if !wasInvalid {
+			t.authState.InvalidSince = now
+		}
+	case statusCode >= 200 && statusCode < 300:
+		if wasInvalid {
+			t.logger.Info("Apifox 凭证已恢复有效")
+		}
+		t.authState.Valid = true
+		t.authState.InvalidSince = time.Time{}
+	default:
+		// 其他状态码无法说明凭证是否有效，保持原状态
+		return
+	}
+	t.authState.Checked = true
+	t.authState.LastStatus = statusCode
+	t.authState.CheckedAt = now
+
+	// 同一次失效只告警一次，恢复后再次失效时重新告警
+	if !t.authState.Valid && !wasInvalid {
+		t.logger.WithField("status", statusCode).Error("Apifox 凭证失效")
+		if t.onAuthFailure != nil {
+			go t.onAuthFailure(t.authState)
+		}
+	}
+}
+
+// isAuthFailure 401/403 表示凭证失效或无权限
+func isAuthFailure(statusCode int) bool {
+	return statusCode == 401 || statusCode == 403
+}
+
+// OnAuthFailure 注册凭证失效时的回调，回调在独立的 goroutine 中执行
+func (t *authTracker) OnAuthFailure(fn func(state AuthState)) {
+	t.authMutex.Lock()
+	defer t.authMutex.Unlock()
+
+	t.onAuthFailure = fn
+}
+
+// SetAuthorization 热替换 Apifox 凭证，凭证状态重置为未检查
+func (t *authTracker) SetAuthorization(token string) {
+	t.authMutex.Lock()
+	defer t.authMutex.Unlock()
+
+	t.authorization = token
+	t.authState = AuthState{}
+	t.logger.Info("Apifox 凭证已更新")
+}
+
+// currentAuthorization 返回当前使用的凭证
+func (t *authTracker) currentAuthorization() string {
+	t.authMutex.RLock()
+	defer t.authMutex.RUnlock()
+
+	return t.authorization
+}
+
+// AuthState 返回当前的凭证状态
+func (t *authTracker) AuthState() AuthState {
+	t.authMutex.RLock()
+	defer t.authMutex.RUnlock()
+
+	return t.authState
+}
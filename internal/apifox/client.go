@@ -1,17 +1,21 @@
 package apifox
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/sirupsen/logrus"
 	"github.com/xhy/api-pulse/config"
+	"github.com/xhy/api-pulse/internal/metrics"
 )
 
-// Client Apifox API 客户端
+// Client Apifox API 客户端，模拟 Apifox 网页端调用其内部接口
 type Client struct {
+	*authTracker
+
 	config     *config.ApifoxConfig
 	httpClient *resty.Client
 	logger     *logrus.Logger
@@ -19,7 +23,21 @@ type Client struct {
 
 // NewClient 创建新的 Apifox 客户端
 func NewClient(cfg *config.ApifoxConfig, logger *logrus.Logger) *Client {
+	tracker := newAuthTracker(cfg.Authorization, logger)
+	return &Client{
+		authTracker: tracker,
+		config:      cfg,
+		httpClient:  newHTTPClient(cfg, tracker, logger),
+		logger:      logger,
+	}
+}
+
+// newHTTPClient 创建两种客户端共用的 HTTP 客户端：超时、日志、指标、凭证状态记录以及限流与重试
+func newHTTPClient(cfg *config.ApifoxConfig, tracker *authTracker, logger *logrus.Logger) *resty.Client {
 	client := resty.New()
+	if cfg.RequestTimeout > 0 {
+		client.SetTimeout(cfg.RequestTimeout)
+	}
 
 	// 添加请求/响应日志拦截器
 	client.OnBeforeRequest(func(c *resty.Client, req *resty.Request) error {
@@ -32,6 +50,11 @@ func NewClient(cfg *config.ApifoxConfig, logger *logrus.Logger) *Client {
 	})
 
 	client.OnAfterResponse(func(c *resty.Client, resp *resty.Response) error {
+		metrics.ObserveApifoxRequest(resp.Request.URL, resp.StatusCode(), resp.Time())
+		if resp.Request.Context().Value(verifyTokenKey{}) == nil {
+			tracker.recordAuthStatus(resp.StatusCode())
+		}
+
 		logger.WithFields(logrus.Fields{
 			"status":       resp.Status(),
 			"response_len": len(resp.Body()),
@@ -46,11 +69,44 @@ func NewClient(cfg *config.ApifoxConfig, logger *logrus.Logger) *Client {
 		return nil
 	})
 
-	return &Client{
-		config:     cfg,
-		httpClient: client,
-		logger:     logger,
+	// 所有请求共享同一个限流器，同步与 Webhook 处理共用请求额度
+	configureResilience(client, cfg, newRateLimiter(cfg), logger)
+
+	// 请求未得到响应（网络错误、超时等）时同样计入指标
+	client.OnError(func(req *resty.Request, err error) {
+		if _, ok := err.(*resty.ResponseError); ok {
+			return
+		}
+		metrics.ObserveApifoxRequest(req.URL, 0, time.Since(req.Time))
+	})
+
+	return client
+}
+
+// VerifyToken 用候选凭证请求一次树形列表，不影响当前凭证及其状态；
+// 凭证被拒绝时返回 ErrUnauthorized
+func (c *Client) VerifyToken(ctx context.Context, token string) error {
+	url := fmt.Sprintf("%s/projects/%s/api-tree-list?locale=zh-CN",
+		c.config.BaseURL, c.config.ProjectID)
+
+	resp, err := c.httpClient.R().
+		SetContext(context.WithValue(ctx, verifyTokenKey{}, true)).
+		SetHeader("authorization", fmt.Sprintf("Bearer %s", token)).
+		SetHeader("x-branch-id", c.config.BranchID).
+		SetHeader("x-project-id", c.config.ProjectID).
+		SetHeader("x-client-mode", "web").
+		SetHeader("x-client-version", "2.7.2-alpha.2").
+		Get(url)
+	if err != nil {
+		return fmt.Errorf("验证 Apifox 凭证失败: %w", err)
 	}
+	if isAuthFailure(resp.StatusCode()) {
+		return fmt.Errorf("验证 Apifox 凭证失败: %w (HTTP %d)", ErrUnauthorized, resp.StatusCode())
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("验证 Apifox 凭证失败: HTTP %d", resp.StatusCode())
+	}
+	return nil
 }
 
 // GetConfig 返回客户端配置
@@ -58,8 +114,8 @@ func (c *Client) GetConfig() *config.ApifoxConfig {
 	return c.config
 }
 
-// GetApiTreeList 获取项目的 API 树形列表
-func (c *Client) GetApiTreeList() (*ApiTreeListResponse, error) {
+// GetApiTreeList 获取项目的 API 树形列表，ctx 取消时请求立即中止
+func (c *Client) GetApiTreeList(ctx context.Context) (*ApiTreeListResponse, error) {
 	url := fmt.Sprintf("%s/projects/%s/api-tree-list?locale=zh-CN",
 		c.config.BaseURL, c.config.ProjectID)
 
@@ -70,12 +126,13 @@ func (c *Client) GetApiTreeList() (*ApiTreeListResponse, error) {
 	}).Info("正在获取 API 树形列表")
 
 	// 添加更多诊断信息
-	authTokenLength := len(c.config.Authorization)
+	authorization := c.currentAuthorization()
+	authTokenLength := len(authorization)
 	authTokenPrefix := ""
 	if authTokenLength > 10 {
-		authTokenPrefix = c.config.Authorization[:10] + "..."
+		authTokenPrefix = authorization[:10] + "..."
 	} else if authTokenLength > 0 {
-		authTokenPrefix = c.config.Authorization + "..."
+		authTokenPrefix = authorization + "..."
 	} else {
 		authTokenPrefix = "未设置"
 	}
@@ -87,7 +144,8 @@ func (c *Client) GetApiTreeList() (*ApiTreeListResponse, error) {
 
 	// 创建与curl命令类似的请求
 	request := c.httpClient.R().
-		SetHeader("authorization", fmt.Sprintf("Bearer %s", c.config.Authorization)).
+		SetContext(ctx).
+		SetHeader("authorization", fmt.Sprintf("Bearer %s", authorization)).
 		SetHeader("x-branch-id", c.config.BranchID).
 		SetHeader("x-project-id", c.config.ProjectID).
 		// 添加更多用户curl请求中使用的头信息
@@ -124,6 +182,9 @@ func (c *Client) GetApiTreeList() (*ApiTreeListResponse, error) {
 			"status_code": resp.StatusCode(),
 			"response":    string(resp.Body()),
 		}).Error("API 返回非成功状态码")
+		if isAuthFailure(resp.StatusCode()) {
+			return nil, fmt.Errorf("API 请求失败: %w (HTTP %d)", ErrUnauthorized, resp.StatusCode())
+		}
 		return nil, fmt.Errorf("API 请求失败: HTTP %d", resp.StatusCode())
 	}
 
@@ -143,52 +204,17 @@ func (c *Client) GetApiTreeList() (*ApiTreeListResponse, error) {
 		return nil, err
 	}
 
-	// 使用更详细的日志输出
 	c.logger.WithFields(logrus.Fields{
-		"success":   response.Success,
-		"data_type": fmt.Sprintf("%T", response.Data),
+		"success":    response.Success,
+		"root_count": len(response.Data.Roots),
+		"api_count":  len(response.Data.Apis()),
 	}).Info("解析后的 API 树形列表基本信息")
 
-	// 根据 Data 的具体类型添加特定日志
-	switch data := response.Data.(type) {
-	case map[string]interface{}:
-		c.logger.WithField("map_keys", fmt.Sprintf("%v", getMapKeys(data))).Info("Data 是对象类型，包含的键")
-	case []interface{}:
-		c.logger.WithField("array_length", len(data)).Info("Data 是数组类型，包含的元素数量")
-		// 如果是数组，记录第一个元素的类型和内容
-		if len(data) > 0 {
-			firstItem := data[0]
-			c.logger.WithFields(logrus.Fields{
-				"first_item_type": fmt.Sprintf("%T", firstItem),
-			}).Debug("数组中第一个元素的信息")
-
-			if m, ok := firstItem.(map[string]interface{}); ok {
-				c.logger.WithFields(logrus.Fields{
-					"first_item_keys": fmt.Sprintf("%v", getMapKeys(m)),
-				}).Debug("第一个元素的键")
-			}
-		}
-	default:
-		c.logger.WithField("exact_type", fmt.Sprintf("%T", response.Data)).Info("Data 是其他类型")
-	}
-
-	// 数据详情
-	json.Marshal(response.Data)
-
 	return &response, nil
 }
 
-// getMapKeys 获取 map 的所有键，辅助函数
-func getMapKeys(m map[string]interface{}) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	return keys
-}
-
-// GetApiDetail 获取单个 API 的详细信息
-func (c *Client) GetApiDetail(apiKey string) (*ApiDetailResponse, error) {
+// GetApiDetail 获取单个 API 的详细信息，ctx 取消时请求立即中止
+func (c *Client) GetApiDetail(ctx context.Context, apiKey string) (*ApiDetailResponse, error) {
 	// 从 apiKey 中提取 ID，格式为 "apiDetail.ID"
 	var apiID string
 	_, err := fmt.Sscanf(apiKey, "apiDetail.%s", &apiID)
@@ -202,7 +228,8 @@ func (c *Client) GetApiDetail(apiKey string) (*ApiDetailResponse, error) {
 
 	// 创建与树形列表请求相同格式的请求
 	request := c.httpClient.R().
-		SetHeader("authorization", fmt.Sprintf("Bearer %s", c.config.Authorization)).
+		SetContext(ctx).
+		SetHeader("authorization", fmt.Sprintf("Bearer %s", c.currentAuthorization())).
 		SetHeader("x-branch-id", c.config.BranchID).
 		SetHeader("x-project-id", c.config.ProjectID).
 		// 添加更多请求头
@@ -235,6 +262,9 @@ func (c *Client) GetApiDetail(apiKey string) (*ApiDetailResponse, error) {
 			"status_code": resp.StatusCode(),
 			"response":    string(resp.Body()),
 		}).Error("API 详情请求返回非成功状态码")
+		if isAuthFailure(resp.StatusCode()) {
+			return nil, fmt.Errorf("API 详情请求失败: %w (HTTP %d)", ErrUnauthorized, resp.StatusCode())
+		}
 		return nil, fmt.Errorf("API 详情请求失败: HTTP %d", resp.StatusCode())
 	}
 
@@ -283,7 +313,47 @@ func (c *Client) GetApiDetail(apiKey string) (*ApiDetailResponse, error) {
 		c.logger.Warn("API 详情响应中的 data 字段是空对象")
 	}
 
-	// 构建 ApiDetail 对象
+	// 构建 ApiDetail 对象，同时保留原始 JSON
+	detail := parseApiDetail(dataMap)
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body(), &envelope); err == nil {
+		detail.Raw = envelope.Data
+	}
+
+	// 构建并返回 ApiDetailResponse
+	return &ApiDetailResponse{
+		Success: success,
+		Data:    detail,
+	}, nil
+}
+
+// GetApiMappings 获取轻量级的API映射信息
+// 此方法专门用于在收到webhook时快速获取所有API的基本映射信息
+func (c *Client) GetApiMappings(ctx context.Context) (map[string]ApiBasic, error) {
+	// 获取API树形列表
+	resp, err := c.GetApiTreeList(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("获取API映射时无法获取API树形列表")
+		return nil, err
+	}
+
+	if resp == nil || !resp.Success {
+		c.logger.Error("获取API映射失败：API树形列表返回非成功状态")
+		return nil, fmt.Errorf("API树形列表请求未成功")
+	}
+
+	// 结果映射，使用"method path"作为键，包括各级目录下的 API
+	mappings := resp.Data.Mappings()
+
+	c.logger.WithField("mapping_count", len(mappings)).Info("成功获取API映射信息")
+
+	return mappings, nil
+}
+
+// parseApiDetail 从详情响应的 data 字段中提取 API 详情，字段类型不符时忽略该字段
+func parseApiDetail(dataMap map[string]interface{}) ApiDetail {
 	detail := ApiDetail{}
 
 	// 提取基本字段
@@ -355,97 +425,37 @@ func (c *Client) GetApiDetail(apiKey string) (*ApiDetailResponse, error) {
 		if schema, exists := rbRaw["jsonSchema"]; exists {
 			rb.JsonSchema = schema
 		}
-		// 处理 parameters
-		if params, exists := rbRaw["parameters"].([]interface{}); exists {
-			for _, p := range params {
-				if paramMap, ok := p.(map[string]interface{}); ok {
-					param := Parameter{}
-					if id, ok := paramMap["id"].(string); ok {
-						param.ID = id
-					}
-					if name, ok := paramMap["name"].(string); ok {
-						param.Name = name
-					}
-					if required, ok := paramMap["required"].(bool); ok {
-						param.Required = required
+		rb.Parameters = parseParameterList(rbRaw["parameters"])
+
+		// 处理请求示例
+		if examples, exists := rbRaw["examples"].([]interface{}); exists {
+			for _, e := range examples {
+				if exampleMap, ok := e.(map[string]interface{}); ok {
+					example := Example{Value: exampleMap["value"]}
+					if name, ok := exampleMap["name"].(string); ok {
+						example.Name = name
 					}
-					if desc, ok := paramMap["description"].(string); ok {
-						param.Description = desc
+					if mediaType, ok := exampleMap["mediaType"].(string); ok {
+						example.MediaType = mediaType
 					}
-					if typ, ok := paramMap["type"].(string); ok {
-						param.Type = typ
+					if desc, ok := exampleMap["description"].(string); ok {
+						example.Description = desc
 					}
-					if enable, ok := paramMap["enable"].(bool); ok {
-						param.Enable = enable
-					}
-					rb.Parameters = append(rb.Parameters, param)
+					rb.Examples = append(rb.Examples, example)
 				}
 			}
 		}
 		detail.RequestBody = rb
 	}
 
-	// 处理 parameters
+	// 处理 parameters：查询、路径、请求头和 Cookie 参数
 	if paramsRaw, exists := dataMap["parameters"].(map[string]interface{}); exists {
-		params := Parameters{}
-
-		// 处理 query 参数
-		if queryParams, exists := paramsRaw["query"].([]interface{}); exists {
-			for _, p := range queryParams {
-				if paramMap, ok := p.(map[string]interface{}); ok {
-					param := Parameter{}
-					if id, ok := paramMap["id"].(string); ok {
-						param.ID = id
-					}
-					if name, ok := paramMap["name"].(string); ok {
-						param.Name = name
-					}
-					if required, ok := paramMap["required"].(bool); ok {
-						param.Required = required
-					}
-					if desc, ok := paramMap["description"].(string); ok {
-						param.Description = desc
-					}
-					if typ, ok := paramMap["type"].(string); ok {
-						param.Type = typ
-					}
-					if enable, ok := paramMap["enable"].(bool); ok {
-						param.Enable = enable
-					}
-					params.Query = append(params.Query, param)
-				}
-			}
-		}
-
-		// 处理 path 参数
-		if pathParams, exists := paramsRaw["path"].([]interface{}); exists {
-			for _, p := range pathParams {
-				if paramMap, ok := p.(map[string]interface{}); ok {
-					param := Parameter{}
-					if id, ok := paramMap["id"].(string); ok {
-						param.ID = id
-					}
-					if name, ok := paramMap["name"].(string); ok {
-						param.Name = name
-					}
-					if required, ok := paramMap["required"].(bool); ok {
-						param.Required = required
-					}
-					if desc, ok := paramMap["description"].(string); ok {
-						param.Description = desc
-					}
-					if typ, ok := paramMap["type"].(string); ok {
-						param.Type = typ
-					}
-					if enable, ok := paramMap["enable"].(bool); ok {
-						param.Enable = enable
-					}
-					params.Path = append(params.Path, param)
-				}
-			}
+		detail.Parameters = Parameters{
+			Query:  parseParameterList(paramsRaw["query"]),
+			Path:   parseParameterList(paramsRaw["path"]),
+			Header: parseParameterList(paramsRaw["header"]),
+			Cookie: parseParameterList(paramsRaw["cookie"]),
 		}
-
-		detail.Parameters = params
 	}
 
 	// 处理 responses
@@ -472,110 +482,91 @@ func (c *Client) GetApiDetail(apiKey string) (*ApiDetailResponse, error) {
 				if schema, exists := respMap["jsonSchema"]; exists {
 					resp.JsonSchema = schema
 				}
+				resp.Headers = parseParameterList(respMap["headers"])
 				detail.Responses = append(detail.Responses, resp)
 			}
 		}
 	}
 
-	// 处理 commonParameters
-	if cpRaw, exists := dataMap["commonParameters"].(map[string]interface{}); exists {
-		cp := CommonParameters{}
-
-		// 处理 header 参数
-		if headers, exists := cpRaw["header"].([]interface{}); exists {
-			for _, h := range headers {
-				if headerMap, ok := h.(map[string]interface{}); ok {
-					header := HeaderParam{}
-					if name, ok := headerMap["name"].(string); ok {
-						header.Name = name
-					}
-					cp.Header = append(cp.Header, header)
+	// 处理 responseExamples
+	if examplesRaw, exists := dataMap["responseExamples"].([]interface{}); exists {
+		for _, e := range examplesRaw {
+			if exampleMap, ok := e.(map[string]interface{}); ok {
+				example := ResponseExample{}
+				if id, ok := exampleMap["id"].(float64); ok {
+					example.ID = int(id)
+				}
+				if responseId, ok := exampleMap["responseId"].(float64); ok {
+					example.ResponseID = int(responseId)
+				}
+				if name, ok := exampleMap["name"].(string); ok {
+					example.Name = name
 				}
+				if data, ok := exampleMap["data"].(string); ok {
+					example.Data = data
+				}
+				detail.ResponseExamples = append(detail.ResponseExamples, example)
 			}
 		}
-
-		detail.CommonParameters = cp
 	}
 
-	// 构建并返回 ApiDetailResponse
-	return &ApiDetailResponse{
-		Success: success,
-		Data:    detail,
-	}, nil
-}
+	// 处理 commonParameters
+	if cpRaw, exists := dataMap["commonParameters"].(map[string]interface{}); exists {
+		cp := CommonParameters{}
+		cp.Query, _ = cpRaw["query"].([]interface{})
+		cp.Body, _ = cpRaw["body"].([]interface{})
+		cp.Cookie, _ = cpRaw["cookie"].([]interface{})
 
-// GetApiMappings 获取轻量级的API映射信息
-// 此方法专门用于在收到webhook时快速获取所有API的基本映射信息
-func (c *Client) GetApiMappings() (map[string]ApiBasic, error) {
-	// 获取API树形列表
-	resp, err := c.GetApiTreeList()
-	if err != nil {
-		c.logger.WithError(err).Error("获取API映射时无法获取API树形列表")
-		return nil, err
-	}
+		// header 参数与请求头一样保存类型、必填、说明和示例
+		cp.Header = parseParameterList(cpRaw["header"])
 
-	if resp == nil || !resp.Success {
-		c.logger.Error("获取API映射失败：API树形列表返回非成功状态")
-		return nil, fmt.Errorf("API树形列表请求未成功")
+		detail.CommonParameters = cp
 	}
 
-	// 结果映射，使用"method path"作为键
-	mappings := make(map[string]ApiBasic)
-
-	// 递归提取所有API基本信息
-	c.extractApiMappingsFromTree(resp.Data, mappings)
-
-	c.logger.WithField("mapping_count", len(mappings)).Info("成功获取API映射信息")
+	// 鉴权设置原样保存
+	if auth, exists := dataMap["auth"]; exists {
+		detail.Auth = auth
+	}
 
-	return mappings, nil
+	return detail
 }
 
-// extractApiMappingsFromTree 从树形结构中递归提取API映射
-func (c *Client) extractApiMappingsFromTree(data interface{}, mappings map[string]ApiBasic) {
-	// 处理数组类型数据
-	if items, ok := data.([]interface{}); ok {
-		for _, item := range items {
-			c.extractApiMappingsFromTree(item, mappings)
-		}
-		return
+// parseParameterList 提取参数列表，跳过格式不正确的项
+func parseParameterList(raw interface{}) []Parameter {
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil
 	}
 
-	// 处理映射类型数据
-	if item, ok := data.(map[string]interface{}); ok {
-		// 检查是否是API类型
-		if typeStr, ok := item["type"].(string); ok && typeStr == "apiDetail" {
-			// 提取API基本信息
-			if apiData, ok := item["api"].(map[string]interface{}); ok {
-				var basic ApiBasic
-
-				// 提取关键字段
-				if id, ok := apiData["id"].(float64); ok {
-					basic.ID = int(id)
-				}
-				if name, ok := apiData["name"].(string); ok {
-					basic.Name = name
-				}
-				if method, ok := apiData["method"].(string); ok {
-					basic.Method = method
-				}
-				if path, ok := apiData["path"].(string); ok {
-					basic.Path = path
-				}
-				if responsibleId, ok := apiData["responsibleId"].(float64); ok {
-					basic.ResponsibleID = int(responsibleId)
-				}
-
-				// 如果方法和路径都不为空，添加到映射中
-				if basic.Method != "" && basic.Path != "" {
-					key := strings.ToLower(basic.Method) + " " + basic.Path
-					mappings[key] = basic
-				}
-			}
+	var params []Parameter
+	for _, p := range list {
+		paramMap, ok := p.(map[string]interface{})
+		if !ok {
+			continue
 		}
-
-		// 递归处理子项
-		if children, ok := item["children"]; ok && children != nil {
-			c.extractApiMappingsFromTree(children, mappings)
+		param := Parameter{}
+		if id, ok := paramMap["id"].(string); ok {
+			param.ID = id
+		}
+		if name, ok := paramMap["name"].(string); ok {
+			param.Name = name
+		}
+		if required, ok := paramMap["required"].(bool); ok {
+			param.Required = required
+		}
+		if desc, ok := paramMap["description"].(string); ok {
+			param.Description = desc
+		}
+		if typ, ok := paramMap["type"].(string); ok {
+			param.Type = typ
+		}
+		if enable, ok := paramMap["enable"].(bool); ok {
+			param.Enable = enable
+		}
+		if example, ok := paramMap["example"]; ok {
+			param.Example = example
 		}
+		params = append(params, param)
 	}
+	return params
 }
@@ -5,15 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/config"
 )
 
 // DiffService API 差异比较服务
 type DiffService struct {
-	logger *logrus.Logger
+	logger      *logrus.Logger
+	ignoreRules []ignoreRule
 }
 
 // NewDiffService 创建新的差异比较服务
@@ -23,7 +26,19 @@ func NewDiffService(logger *logrus.Logger) *DiffService {
 	}
 }
 
-// CompareApis 比较两个 API 的差异
+// SetIgnoreRules 设置比较时忽略的路径，需在开始比较前调用
+func (s *DiffService) SetIgnoreRules(rules []config.IgnoreRule) {
+	s.ignoreRules = make([]ignoreRule, 0, len(rules))
+	for _, rule := range rules {
+		s.ignoreRules = append(s.ignoreRules, compileIgnoreRule(rule))
+	}
+	if len(rules) > 0 {
+		s.logger.WithField("count", len(rules)).Info("已加载差异忽略规则")
+	}
+}
+
+// CompareApis 比较两个 API 的差异，比较前先将双方规范化（见 Normalize）并移除忽略规则命中的内容，
+// 没有任何可读变更内容的部分不视为变更
 func (s *DiffService) CompareApis(oldApi, newApi ApiDetail, modifierName, modifiedTime string) *ApiDiff {
 	diff := &ApiDiff{
 		ApiID:        newApi.ID,
@@ -37,11 +52,27 @@ func (s *DiffService) CompareApis(oldApi, newApi ApiDetail, modifierName, modifi
 		ModifiedTime: modifiedTime,
 	}
 
+	// 字段顺序只保存在 x-apifox-orders 中，规范化时会被移除，先读出来作为推测字段重命名的依据
+	orders := fieldOrders{Old: schemaFieldOrder(oldApi.RequestBody.JsonSchema), New: schemaFieldOrder(newApi.RequestBody.JsonSchema)}
+
+	responseOrders := responseFieldOrders(oldApi.Responses, newApi.Responses)
+
+	oldApi, newApi = Normalize(oldApi), Normalize(newApi)
+	if rules := s.applicableIgnoreRules(oldApi, newApi); len(rules) > 0 {
+		oldApi, newApi = applyIgnoreRules(oldApi, rules), applyIgnoreRules(newApi, rules)
+	}
+
 	// 比较HTTP方法
 	diff.MethodDiff = strings.ToLower(oldApi.Method) != strings.ToLower(newApi.Method)
 
-	// 比较路径
-	diff.PathDiff = oldApi.Path != newApi.Path
+	// 比较路径，只有路径参数改名时记为参数重命名而不是路径变更，调用方使用的 URL 不受影响
+	if oldApi.Path != newApi.Path {
+		if renames, ok := PathParamRenames(oldApi.Path, newApi.Path); ok && len(renames) > 0 {
+			diff.PathParamRenames = renames
+		} else {
+			diff.PathDiff = true
+		}
+	}
 
 	// 比较请求体 - 详细分析变更内容
 	oldRequestBodyJSON, _ := json.Marshal(oldApi.RequestBody)
@@ -49,7 +80,7 @@ func (s *DiffService) CompareApis(oldApi, newApi ApiDetail, modifierName, modifi
 	diff.RequestBodyDiff = !bytes.Equal(oldRequestBodyJSON, newRequestBodyJSON)
 
 	if diff.RequestBodyDiff {
-		var rbDetails strings.Builder
+		var rbDetails diffWriter
 		rbDetails.WriteString("【请求体变更】\n")
 
 		// 检查内容类型变更 - 处理空值和none的情况
@@ -63,12 +94,12 @@ func (s *DiffService) CompareApis(oldApi, newApi ApiDetail, modifierName, modifi
 		}
 
 		if oldMediaType != newMediaType {
-			rbDetails.WriteString(fmt.Sprintf("* 请求体类型: %s -> %s\n", oldMediaType, newMediaType))
+			rbDetails.breakingf("* 请求体类型: %s -> %s\n", oldMediaType, newMediaType)
 		}
 
 		// 检查请求体类型变更
 		if oldApi.RequestBody.Type != newApi.RequestBody.Type {
-			rbDetails.WriteString(fmt.Sprintf("* 请求体类型: %s -> %s\n", oldApi.RequestBody.Type, newApi.RequestBody.Type))
+			rbDetails.breakingf("* 请求体类型: %s -> %s\n", oldApi.RequestBody.Type, newApi.RequestBody.Type)
 		}
 
 		// 检查请求体结构(JsonSchema)变更
@@ -76,7 +107,7 @@ func (s *DiffService) CompareApis(oldApi, newApi ApiDetail, modifierName, modifi
 		newSchemaJSON, _ := json.Marshal(newApi.RequestBody.JsonSchema)
 		if !bytes.Equal(oldSchemaJSON, newSchemaJSON) {
 			// 直接分析JSON结构变化
-			if err := analyzeJsonSchemaDiff(&rbDetails, oldApi.RequestBody.JsonSchema, newApi.RequestBody.JsonSchema); err != nil {
+			if err := analyzeJsonSchemaDiff(&rbDetails, oldApi.RequestBody.JsonSchema, newApi.RequestBody.JsonSchema, orders); err != nil {
 				s.logger.WithError(err).Warn("分析请求体JSON结构变化失败")
 			}
 		}
@@ -95,207 +126,105 @@ func (s *DiffService) CompareApis(oldApi, newApi ApiDetail, modifierName, modifi
 				// 新增的参数
 				rbDetails.WriteString(fmt.Sprintf("+ 新增参数: %s (%s", newParam.Name, newParam.Type))
 				if newParam.Required {
+					rbDetails.markRequired()
 					rbDetails.WriteString(", 必填")
 				}
 				rbDetails.WriteString(")\n")
 				hasParamChanges = true
-			} else {
-				// 检查参数是否有变化
-				if newParam.Type != oldParam.Type || newParam.Required != oldParam.Required ||
-					newParam.Description != oldParam.Description || newParam.Enable != oldParam.Enable {
-					rbDetails.WriteString(fmt.Sprintf("* 修改参数: %s\n", newParam.Name))
-
-					if newParam.Type != oldParam.Type {
-						rbDetails.WriteString(fmt.Sprintf("  - 类型: %s -> %s\n", oldParam.Type, newParam.Type))
-					}
-
-					if newParam.Required != oldParam.Required {
-						if newParam.Required {
-							rbDetails.WriteString("  - 变为必填\n")
-						} else {
-							rbDetails.WriteString("  - 变为非必填\n")
-						}
-					}
-
-					if newParam.Description != oldParam.Description {
-						rbDetails.WriteString(fmt.Sprintf("  - 描述变更: %s -> %s\n", oldParam.Description, newParam.Description))
-					}
-
-					if newParam.Enable != oldParam.Enable {
-						if newParam.Enable {
-							rbDetails.WriteString("  - 已启用\n")
-						} else {
-							rbDetails.WriteString("  - 已禁用\n")
-						}
-					}
-
-					hasParamChanges = true
-				}
+			} else if parameterChanged(oldParam, newParam) {
+				// 参数有变化
+				rbDetails.WriteString(fmt.Sprintf("* 修改参数: %s\n", newParam.Name))
+				writeParameterChanges(&rbDetails, "  ", oldParam, newParam)
+				hasParamChanges = true
 			}
 
 			// 从旧参数映射中删除已处理的参数
 			delete(oldParamMap, newParam.Name)
 		}
 
-		// 检查已删除的参数
-		for name, param := range oldParamMap {
-			rbDetails.WriteString(fmt.Sprintf("- 删除参数: %s (%s)\n", name, param.Type))
-			hasParamChanges = true
+		// 检查已删除的参数，按旧参数的顺序输出
+		for _, param := range oldApi.RequestBody.Parameters {
+			if _, removed := oldParamMap[param.Name]; removed {
+				rbDetails.breakingf("- 删除参数: %s (%s)\n", param.Name, param.Type)
+				delete(oldParamMap, param.Name)
+				hasParamChanges = true
+			}
 		}
 
 		if hasParamChanges {
 			rbDetails.WriteString("\n")
 		}
 
+		// 检查请求示例变更
+		oldRequestExamplesJSON, _ := json.Marshal(oldApi.RequestBody.Examples)
+		newRequestExamplesJSON, _ := json.Marshal(newApi.RequestBody.Examples)
+		if !bytes.Equal(oldRequestExamplesJSON, newRequestExamplesJSON) {
+			rbDetails.WriteString("* 请求示例变更\n")
+		}
+
 		diff.RequestBodyDetail = rbDetails.String()
+		diff.Breaking = diff.Breaking || rbDetails.breaking
 	}
 
-	// 比较参数 - 详细分析变更内容
+	// 比较参数 - 详细分析变更内容，包括公共参数
 	oldParamsJSON, _ := json.Marshal(oldApi.Parameters)
 	newParamsJSON, _ := json.Marshal(newApi.Parameters)
-	diff.ParametersDiff = !bytes.Equal(oldParamsJSON, newParamsJSON)
+	oldCommonJSON, _ := json.Marshal(oldApi.CommonParameters)
+	newCommonJSON, _ := json.Marshal(newApi.CommonParameters)
+	commonDiff := !bytes.Equal(oldCommonJSON, newCommonJSON)
+	diff.ParametersDiff = !bytes.Equal(oldParamsJSON, newParamsJSON) || commonDiff || len(diff.PathParamRenames) > 0
 
 	if diff.ParametersDiff {
-		var paramDetails strings.Builder
+		var paramDetails diffWriter
 
 		// 比较查询参数(Query Parameters)
 		paramDetails.WriteString("【查询参数(Query)变更】\n")
-		hasQueryChanges := false
-
-		// 创建旧参数的映射，用于快速查找
-		oldQueryParams := make(map[string]Parameter)
-		for _, p := range oldApi.Parameters.Query {
-			oldQueryParams[p.Name] = p
-		}
-
-		// 检查新增或修改的参数
-		for _, newParam := range newApi.Parameters.Query {
-			oldParam, exists := oldQueryParams[newParam.Name]
-			if !exists {
-				// 新增的参数
-				paramDetails.WriteString(fmt.Sprintf("+ 新增: %s (%s", newParam.Name, newParam.Type))
-				if newParam.Required {
-					paramDetails.WriteString(", 必填")
-				}
-				paramDetails.WriteString(")\n")
-				hasQueryChanges = true
-			} else {
-				// 检查参数是否有变化
-				if newParam.Type != oldParam.Type || newParam.Required != oldParam.Required ||
-					newParam.Description != oldParam.Description || newParam.Enable != oldParam.Enable {
-					paramDetails.WriteString(fmt.Sprintf("* 修改: %s\n", newParam.Name))
-					if newParam.Type != oldParam.Type {
-						paramDetails.WriteString(fmt.Sprintf("  - 类型: %s -> %s\n", oldParam.Type, newParam.Type))
-					}
-					if newParam.Required != oldParam.Required {
-						if newParam.Required {
-							paramDetails.WriteString("  - 变为必填\n")
-						} else {
-							paramDetails.WriteString("  - 变为非必填\n")
-						}
-					}
-					if newParam.Description != oldParam.Description {
-						paramDetails.WriteString(fmt.Sprintf("  - 描述变更: %s -> %s\n", oldParam.Description, newParam.Description))
-					}
-					if newParam.Enable != oldParam.Enable {
-						if newParam.Enable {
-							paramDetails.WriteString("  - 已启用\n")
-						} else {
-							paramDetails.WriteString("  - 已禁用\n")
-						}
-					}
-					hasQueryChanges = true
-				}
-			}
-
-			// 从老参数映射中删除已处理的参数
-			delete(oldQueryParams, newParam.Name)
-		}
-
-		// 检查已删除的参数 - 直接遍历剩余的oldQueryParams即可找到被删除的参数
-		for name, param := range oldQueryParams {
-			paramDetails.WriteString(fmt.Sprintf("- 删除: %s (%s)\n", name, param.Type))
-			hasQueryChanges = true
-		}
-
-		if !hasQueryChanges {
+		if !writeParameterListDiff(&paramDetails, "", oldApi.Parameters.Query, newApi.Parameters.Query) {
 			paramDetails.WriteString("无变更\n")
 		}
 
-		// 比较路径参数(Path Parameters)
+		// 比较路径参数(Path Parameters)，重命名的参数按新名称与新参数对应后再比较
 		paramDetails.WriteString("\n【路径参数(Path)变更】\n")
-		hasPathChanges := false
-
-		// 创建旧参数的映射
-		oldPathParams := make(map[string]Parameter)
-		for _, p := range oldApi.Parameters.Path {
-			oldPathParams[p.Name] = p
+		for _, rename := range diff.PathParamRenames {
+			paramDetails.WriteString(fmt.Sprintf("* 重命名: %s -> %s\n", rename.Old, rename.New))
 		}
-
-		// 检查新增或修改的参数
-		for _, newParam := range newApi.Parameters.Path {
-			oldParam, exists := oldPathParams[newParam.Name]
-			if !exists {
-				// 新增的参数
-				paramDetails.WriteString(fmt.Sprintf("+ 新增: %s (%s", newParam.Name, newParam.Type))
-				if newParam.Required {
-					paramDetails.WriteString(", 必填")
-				}
-				paramDetails.WriteString(")\n")
-				hasPathChanges = true
-			} else {
-				// 检查参数是否有变化
-				if newParam.Type != oldParam.Type || newParam.Required != oldParam.Required ||
-					newParam.Description != oldParam.Description || newParam.Enable != oldParam.Enable {
-					paramDetails.WriteString(fmt.Sprintf("* 修改: %s\n", newParam.Name))
-					if newParam.Type != oldParam.Type {
-						paramDetails.WriteString(fmt.Sprintf("  - 类型: %s -> %s\n", oldParam.Type, newParam.Type))
-					}
-					if newParam.Required != oldParam.Required {
-						if newParam.Required {
-							paramDetails.WriteString("  - 变为必填\n")
-						} else {
-							paramDetails.WriteString("  - 变为非必填\n")
-						}
-					}
-					if newParam.Description != oldParam.Description {
-						paramDetails.WriteString(fmt.Sprintf("  - 描述变更: %s -> %s\n", oldParam.Description, newParam.Description))
-					}
-					if newParam.Enable != oldParam.Enable {
-						if newParam.Enable {
-							paramDetails.WriteString("  - 已启用\n")
-						} else {
-							paramDetails.WriteString("  - 已禁用\n")
-						}
-					}
-					hasPathChanges = true
-				}
-			}
-
-			// 从老参数映射中删除已处理的参数
-			delete(oldPathParams, newParam.Name)
+		oldPathParams := renameParameters(oldApi.Parameters.Path, diff.PathParamRenames)
+		if !writeParameterListDiff(&paramDetails, "", oldPathParams, newApi.Parameters.Path) && len(diff.PathParamRenames) == 0 {
+			paramDetails.WriteString("无变更\n")
 		}
 
-		// 检查已删除的参数 - 直接遍历剩余的oldPathParams即可找到被删除的参数
-		for name, param := range oldPathParams {
-			paramDetails.WriteString(fmt.Sprintf("- 删除: %s (%s)\n", name, param.Type))
-			hasPathChanges = true
+		// 请求头和 Cookie 参数较少使用，只在有变化时输出
+		var headerDetails diffWriter
+		if writeParameterListDiff(&headerDetails, "", oldApi.Parameters.Header, newApi.Parameters.Header) {
+			paramDetails.WriteString("\n【请求头参数(Header)变更】\n")
+			paramDetails.merge(&headerDetails, "")
+		}
+		var cookieDetails diffWriter
+		if writeParameterListDiff(&cookieDetails, "", oldApi.Parameters.Cookie, newApi.Parameters.Cookie) {
+			paramDetails.WriteString("\n【Cookie 参数变更】\n")
+			paramDetails.merge(&cookieDetails, "")
 		}
 
-		if !hasPathChanges {
-			paramDetails.WriteString("无变更\n")
+		if commonDiff {
+			paramDetails.WriteString("\n【公共参数变更】\n")
+			writeCommonParametersDiff(&paramDetails, oldApi.CommonParameters, newApi.CommonParameters)
 		}
 
 		diff.ParametersDetail = paramDetails.String()
+		diff.Breaking = diff.Breaking || paramDetails.breaking
 	}
 
-	// 比较响应
+	// 比较响应及响应示例
 	oldResponsesJSON, _ := json.Marshal(oldApi.Responses)
 	newResponsesJSON, _ := json.Marshal(newApi.Responses)
-	diff.ResponsesDiff = !bytes.Equal(oldResponsesJSON, newResponsesJSON)
-
-	if diff.ResponsesDiff {
-		var respDetails strings.Builder
+	responsesChanged := !bytes.Equal(oldResponsesJSON, newResponsesJSON)
+	oldExamplesJSON, _ := json.Marshal(oldApi.ResponseExamples)
+	newExamplesJSON, _ := json.Marshal(newApi.ResponseExamples)
+	examplesChanged := !bytes.Equal(oldExamplesJSON, newExamplesJSON)
+	diff.ResponsesDiff = responsesChanged || examplesChanged
+
+	respDetails := diffWriter{response: true}
+	if responsesChanged {
 		respDetails.WriteString("【响应状态码变更】\n")
 
 		// 建立旧响应的映射，以状态码为键
@@ -325,7 +254,7 @@ func (s *DiffService) CompareApis(oldApi, newApi ApiDetail, modifierName, modifi
 
 					// 检查内容类型变更
 					if oldResp.ContentType != newResp.ContentType {
-						respDetails.WriteString(fmt.Sprintf("  - 内容类型: %s -> %s\n", oldResp.ContentType, newResp.ContentType))
+						respDetails.breakingf("  - 内容类型: %s -> %s\n", oldResp.ContentType, newResp.ContentType)
 					}
 
 					// 检查描述变更
@@ -333,11 +262,32 @@ func (s *DiffService) CompareApis(oldApi, newApi ApiDetail, modifierName, modifi
 						respDetails.WriteString(fmt.Sprintf("  - 描述变更\n"))
 					}
 
-					// 检查JSON结构变更
+					// 检查JSON结构变更，两边都有结构时按字段逐项比较
 					oldSchemaJSON, _ := json.Marshal(oldResp.JsonSchema)
 					newSchemaJSON, _ := json.Marshal(newResp.JsonSchema)
 					if !bytes.Equal(oldSchemaJSON, newSchemaJSON) {
-						respDetails.WriteString("  - 响应结构变更\n")
+						schemaDetails := diffWriter{response: true}
+						if oldResp.JsonSchema != nil && newResp.JsonSchema != nil {
+							if err := analyzeJsonSchemaDiff(&schemaDetails, oldResp.JsonSchema, newResp.JsonSchema, responseOrders[newResp.Code]); err != nil {
+								s.logger.WithError(err).Warn("分析响应JSON结构变化失败")
+							}
+						}
+						switch {
+						case newResp.JsonSchema == nil:
+							respDetails.breakingf("  - 移除了响应结构\n")
+						case schemaDetails.Len() > 0:
+							respDetails.WriteString("  - 响应结构变更:\n")
+							respDetails.merge(&schemaDetails, "    ")
+						default:
+							respDetails.WriteString("  - 响应结构变更\n")
+						}
+					}
+
+					// 检查响应头变更
+					headerDetails := diffWriter{response: true}
+					if writeParameterListDiff(&headerDetails, "    ", oldResp.Headers, newResp.Headers) {
+						respDetails.WriteString("  - 响应头变更:\n")
+						respDetails.merge(&headerDetails, "")
 					}
 				}
 			}
@@ -346,17 +296,363 @@ func (s *DiffService) CompareApis(oldApi, newApi ApiDetail, modifierName, modifi
 			delete(oldResponseMap, newResp.Code)
 		}
 
-		// 检查已删除的响应状态码
-		for code, resp := range oldResponseMap {
-			respDetails.WriteString(fmt.Sprintf("- 删除状态码: %d (%s)\n", code, resp.Name))
+		// 检查已删除的响应状态码，按旧响应的顺序输出
+		for _, resp := range oldApi.Responses {
+			if _, removed := oldResponseMap[resp.Code]; removed {
+				respDetails.breakingf("- 删除状态码: %d (%s)\n", resp.Code, resp.Name)
+				delete(oldResponseMap, resp.Code)
+			}
+		}
+	}
+
+	if examplesChanged {
+		if responsesChanged {
+			respDetails.WriteString("\n")
 		}
+		respDetails.WriteString("【响应示例变更】\n")
+		writeResponseExamplesDiff(&respDetails, oldApi.ResponseExamples, newApi.ResponseExamples)
+	}
 
+	if diff.ResponsesDiff {
 		diff.ResponsesDetail = respDetails.String()
+		diff.Breaking = diff.Breaking || respDetails.breaking
+	}
+
+	// 比较鉴权设置
+	oldAuthJSON, _ := json.Marshal(oldApi.Auth)
+	newAuthJSON, _ := json.Marshal(newApi.Auth)
+	diff.AuthDiff = !bytes.Equal(oldAuthJSON, newAuthJSON)
+
+	if diff.AuthDiff {
+		oldAuthType, newAuthType := authType(oldApi.Auth), authType(newApi.Auth)
+		if oldAuthType != newAuthType {
+			diff.AuthDetail = fmt.Sprintf("* 鉴权方式: %s -> %s\n", oldAuthType, newAuthType)
+		} else {
+			diff.AuthDetail = fmt.Sprintf("* 鉴权设置变更 (%s)\n", newAuthType)
+		}
 	}
 
+	// 比较基本信息
+	var metaDetails strings.Builder
+	if oldApi.Name != newApi.Name {
+		metaDetails.WriteString(fmt.Sprintf("* 名称: %s -> %s\n", oldApi.Name, newApi.Name))
+	}
+	if oldApi.Status != newApi.Status {
+		metaDetails.WriteString(fmt.Sprintf("* 状态: %s -> %s\n", StatusLabel(oldApi.Status), StatusLabel(newApi.Status)))
+		diff.OldStatus = oldApi.Status
+		diff.NewStatus = newApi.Status
+		diff.Deprecated = IsDeprecatedStatus(newApi.Status) && !IsDeprecatedStatus(oldApi.Status)
+	}
+	if oldApi.ResponsibleID != newApi.ResponsibleID {
+		metaDetails.WriteString(fmt.Sprintf("* 负责人: %d -> %d\n", oldApi.ResponsibleID, newApi.ResponsibleID))
+		diff.OldResponsibleID = oldApi.ResponsibleID
+		diff.NewResponsibleID = newApi.ResponsibleID
+		diff.Handover = true
+	}
+	if oldApi.FolderID != newApi.FolderID {
+		metaDetails.WriteString(fmt.Sprintf("* 目录: %d -> %d\n", oldApi.FolderID, newApi.FolderID))
+	}
+	if oldApi.Visibility != newApi.Visibility {
+		metaDetails.WriteString(fmt.Sprintf("* 可见性: %s -> %s\n", oldApi.Visibility, newApi.Visibility))
+	}
+	if oldApi.Description != newApi.Description {
+		metaDetails.WriteString("* 描述变更\n")
+	}
+	// 标签已在规范化时排序
+	for _, tag := range newApi.Tags {
+		if !contains(oldApi.Tags, tag) {
+			metaDetails.WriteString(fmt.Sprintf("+ 新增标签: %s\n", tag))
+		}
+	}
+	for _, tag := range oldApi.Tags {
+		if !contains(newApi.Tags, tag) {
+			metaDetails.WriteString(fmt.Sprintf("- 删除标签: %s\n", tag))
+		}
+	}
+	diff.MetadataDetail = metaDetails.String()
+	diff.MetadataDiff = diff.MetadataDetail != ""
+
+	dropEmptySections(diff)
+
 	return diff
 }
 
+// applicableIgnoreRules 返回对该 API 生效的忽略规则
+func (s *DiffService) applicableIgnoreRules(oldApi, newApi ApiDetail) []ignoreRule {
+	var rules []ignoreRule
+	for _, rule := range s.ignoreRules {
+		if rule.appliesTo(oldApi, newApi) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// dropEmptySections 清除没有任何可读变更条目的部分，避免只有标题、没有内容的差异触发通知
+func dropEmptySections(diff *ApiDiff) {
+	if diff.RequestBodyDiff && !hasChangeLines(diff.RequestBodyDetail) {
+		diff.RequestBodyDiff, diff.RequestBodyDetail = false, ""
+	}
+	if diff.ParametersDiff && !hasChangeLines(diff.ParametersDetail) {
+		diff.ParametersDiff, diff.ParametersDetail = false, ""
+	}
+	if diff.ResponsesDiff && !hasChangeLines(diff.ResponsesDetail) {
+		diff.ResponsesDiff, diff.ResponsesDetail = false, ""
+	}
+}
+
+// hasChangeLines 差异详情中是否包含以 "+"、"-" 或 "*" 开头的变更条目
+func hasChangeLines(detail string) bool {
+	for _, line := range strings.Split(detail, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "+ ") || strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
+			return true
+		}
+	}
+	return false
+}
+
+// diffWriter 写出差异详情，同时记录是否写出了可能破坏调用方的条目，严重程度据此评估而不是从文本中查找关键字
+type diffWriter struct {
+	strings.Builder
+	breaking bool
+	// response 为 true 时写出的是响应的内容，新增的必填项只是保证多返回内容，不会破坏调用方
+	response bool
+}
+
+// breakingf 写出可能破坏调用方的条目：删除、类型变化或字段重命名等
+func (w *diffWriter) breakingf(format string, args ...interface{}) {
+	w.breaking = true
+	w.WriteString(fmt.Sprintf(format, args...))
+}
+
+// requiredf 写出新增必填项或变为必填的条目
+func (w *diffWriter) requiredf(format string, args ...interface{}) {
+	w.markRequired()
+	w.WriteString(fmt.Sprintf(format, args...))
+}
+
+// markRequired 记录新增了必填项，只有请求中的必填项才会破坏调用方
+func (w *diffWriter) markRequired() {
+	w.breaking = w.breaking || !w.response
+}
+
+// merge 追加单独写出的一段详情，每行加上 indent 前缀
+func (w *diffWriter) merge(other *diffWriter, indent string) {
+	for _, line := range strings.SplitAfter(other.String(), "\n") {
+		if line != "" {
+			w.WriteString(indent + line)
+		}
+	}
+	w.breaking = w.breaking || other.breaking
+}
+
+// writeParameterListDiff 按名称比较两组参数，逐行写出新增、修改和删除的参数，返回是否有变化；
+// indent 为每行的前缀，用于嵌套在其他变更下输出
+func writeParameterListDiff(builder *diffWriter, indent string, oldParams, newParams []Parameter) bool {
+	changed := false
+
+	// 创建旧参数的映射，用于快速查找
+	oldParamMap := make(map[string]Parameter)
+	for _, p := range oldParams {
+		oldParamMap[p.Name] = p
+	}
+
+	// 检查新增或修改的参数
+	for _, newParam := range newParams {
+		oldParam, exists := oldParamMap[newParam.Name]
+		if !exists {
+			// 新增的参数
+			builder.WriteString(fmt.Sprintf("%s+ 新增: %s (%s", indent, newParam.Name, newParam.Type))
+			if newParam.Required {
+				builder.markRequired()
+				builder.WriteString(", 必填")
+			}
+			builder.WriteString(")\n")
+			changed = true
+		} else if parameterChanged(oldParam, newParam) {
+			// 参数有变化
+			builder.WriteString(fmt.Sprintf("%s* 修改: %s\n", indent, newParam.Name))
+			writeParameterChanges(builder, indent+"  ", oldParam, newParam)
+			changed = true
+		}
+
+		// 从旧参数映射中删除已处理的参数
+		delete(oldParamMap, newParam.Name)
+	}
+
+	// 剩余的旧参数即为被删除的参数，按旧参数的顺序输出
+	for _, param := range oldParams {
+		if _, removed := oldParamMap[param.Name]; removed {
+			builder.breakingf("%s- 删除: %s (%s)\n", indent, param.Name, param.Type)
+			delete(oldParamMap, param.Name)
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// parameterChanged 同名参数的定义是否有变化
+func parameterChanged(oldParam, newParam Parameter) bool {
+	return newParam.Type != oldParam.Type || newParam.Required != oldParam.Required ||
+		newParam.Description != oldParam.Description || newParam.Enable != oldParam.Enable ||
+		!reflect.DeepEqual(newParam.Example, oldParam.Example)
+}
+
+// writeParameterChanges 逐行写出同名参数的类型、必填、描述、启用状态和示例变化，indent 为每行的前缀
+func writeParameterChanges(builder *diffWriter, indent string, oldParam, newParam Parameter) {
+	if newParam.Type != oldParam.Type {
+		builder.breakingf("%s- 类型: %s -> %s\n", indent, oldParam.Type, newParam.Type)
+	}
+	if newParam.Required != oldParam.Required {
+		if newParam.Required {
+			builder.requiredf("%s- 变为必填\n", indent)
+		} else {
+			builder.WriteString(indent + "- 变为非必填\n")
+		}
+	}
+	if newParam.Description != oldParam.Description {
+		builder.WriteString(fmt.Sprintf("%s- 描述变更: %s -> %s\n", indent, oldParam.Description, newParam.Description))
+	}
+	if newParam.Enable != oldParam.Enable {
+		if newParam.Enable {
+			builder.WriteString(indent + "- 已启用\n")
+		} else {
+			builder.WriteString(indent + "- 已禁用\n")
+		}
+	}
+	if !reflect.DeepEqual(newParam.Example, oldParam.Example) {
+		builder.WriteString(fmt.Sprintf("%s- 示例: %s -> %s\n", indent, formatValue(oldParam.Example), formatValue(newParam.Example)))
+	}
+}
+
+// renameParameters 返回按重命名列表改名后的参数副本
+func renameParameters(params []Parameter, renames []PathParamRename) []Parameter {
+	if len(renames) == 0 {
+		return params
+	}
+	renamed := make([]Parameter, len(params))
+	for i, p := range params {
+		for _, rename := range renames {
+			if p.Name == rename.Old {
+				p.Name = rename.New
+				break
+			}
+		}
+		renamed[i] = p
+	}
+	return renamed
+}
+
+// writeCommonParametersDiff 写出公共参数的变化：Query、Body、Cookie 只记录名称，写出增减；
+// Header 保存了完整定义，同名的 Header 还会逐项比较
+func writeCommonParametersDiff(builder *diffWriter, oldCommon, newCommon CommonParameters) {
+	for _, group := range []struct {
+		label    string
+		old, new []interface{}
+	}{
+		{"Query", oldCommon.Query, newCommon.Query},
+		{"Body", oldCommon.Body, newCommon.Body},
+		{"Cookie", oldCommon.Cookie, newCommon.Cookie},
+	} {
+		oldNames := commonParameterNames(group.old)
+		newNames := commonParameterNames(group.new)
+		for _, name := range newNames {
+			if !contains(oldNames, name) {
+				builder.WriteString(fmt.Sprintf("+ 新增 %s: %s\n", group.label, name))
+			}
+		}
+		for _, name := range oldNames {
+			if !contains(newNames, name) {
+				builder.breakingf("- 删除 %s: %s\n", group.label, name)
+			}
+		}
+	}
+
+	oldHeaders := make(map[string]Parameter, len(oldCommon.Header))
+	for _, h := range oldCommon.Header {
+		oldHeaders[h.Name] = h
+	}
+	newHeaders := make(map[string]bool, len(newCommon.Header))
+	for _, h := range newCommon.Header {
+		newHeaders[h.Name] = true
+		oldHeader, exists := oldHeaders[h.Name]
+		if !exists {
+			builder.WriteString(fmt.Sprintf("+ 新增 Header: %s\n", h.Name))
+			if h.Required {
+				builder.markRequired()
+			}
+		} else if parameterChanged(oldHeader, h) {
+			builder.WriteString(fmt.Sprintf("* 修改 Header: %s\n", h.Name))
+			writeParameterChanges(builder, "  ", oldHeader, h)
+		}
+	}
+	for _, h := range oldCommon.Header {
+		if !newHeaders[h.Name] {
+			builder.breakingf("- 删除 Header: %s\n", h.Name)
+		}
+	}
+}
+
+// commonParameterNames 提取公共参数的名称
+func commonParameterNames(params []interface{}) []string {
+	var names []string
+	for _, p := range params {
+		if m, ok := p.(map[string]interface{}); ok {
+			if name, ok := m["name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// writeResponseExamplesDiff 按名称比较响应示例，逐行写出新增、修改和删除的示例
+func writeResponseExamplesDiff(builder *diffWriter, oldExamples, newExamples []ResponseExample) {
+	oldExampleMap := make(map[string]ResponseExample)
+	for _, e := range oldExamples {
+		oldExampleMap[e.Name] = e
+	}
+
+	for _, newExample := range newExamples {
+		oldExample, exists := oldExampleMap[newExample.Name]
+		if !exists {
+			builder.WriteString(fmt.Sprintf("+ 新增示例: %s\n", newExample.Name))
+		} else if oldExample.Data != newExample.Data || oldExample.ResponseID != newExample.ResponseID {
+			builder.WriteString(fmt.Sprintf("* 修改示例: %s\n", newExample.Name))
+		}
+		delete(oldExampleMap, newExample.Name)
+	}
+
+	for _, e := range oldExamples {
+		if _, removed := oldExampleMap[e.Name]; removed {
+			builder.WriteString(fmt.Sprintf("- 删除示例: %s\n", e.Name))
+			delete(oldExampleMap, e.Name)
+		}
+	}
+}
+
+// authType 返回鉴权设置的类型，未设置时返回 "无"
+func authType(auth interface{}) string {
+	switch v := auth.(type) {
+	case nil:
+		return "无"
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return "无"
+		}
+		if t, ok := v["type"].(string); ok && t != "" {
+			return t
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return "无"
+		}
+	}
+	return "自定义"
+}
+
 // ParseWebhookContent 解析 webhook 内容以获取 API 信息
 func ParseWebhookContent(content string) (string, string, error) {
 	lines := strings.Split(content, "\n")
@@ -378,73 +674,6 @@ func ParseWebhookContent(content string) (string, string, error) {
 	return apiName, apiPath, nil
 }
 
-// ExtractApiKeyFromTreeItem 从 API 树形列表项中提取 API Key
-func ExtractApiKeyFromTreeItem(apiName string, items []ApiTreeItem) (string, error) {
-	for _, item := range items {
-		if item.Type == "apiDetail" && item.Name == apiName {
-			return item.Key, nil
-		}
-
-		// 递归搜索子项
-		if len(item.Children) > 0 {
-			// 先尝试解析为 ApiTreeItem 数组
-			var children []ApiTreeItem
-			if err := json.Unmarshal(item.Children, &children); err == nil {
-				// 递归检查子项
-				for _, child := range children {
-					if child.Type == "apiDetail" && child.Name == apiName {
-						return child.Key, nil
-					}
-
-					// 再次递归检查这个子项的子项
-					if len(child.Children) > 0 {
-						childKey, err := ExtractApiKeyFromTreeItem(apiName, []ApiTreeItem{child})
-						if err == nil {
-							return childKey, nil
-						}
-					}
-				}
-			} else {
-				// 如果解析失败，尝试解析为通用 map 数组
-				var genericChildren []map[string]interface{}
-				if err := json.Unmarshal(item.Children, &genericChildren); err == nil {
-					for _, child := range genericChildren {
-						// 检查是否为 apiDetail 类型
-						if childType, ok := child["type"].(string); ok && childType == "apiDetail" {
-							// 检查名称是否匹配
-							if childName, ok := child["name"].(string); ok && childName == apiName {
-								// 返回找到的 key
-								if key, ok := child["key"].(string); ok {
-									return key, nil
-								}
-							}
-						}
-
-						// 检查这个子项是否有子项
-						if childrenRaw, ok := child["children"]; ok {
-							// 将子项的 children 转为 JSON
-							childrenJSON, err := json.Marshal(childrenRaw)
-							if err == nil && len(childrenJSON) > 2 { // 检查 JSON 是否不为 [] 或 {}
-								// 创建一个临时 ApiTreeItem 以进行递归
-								tempItem := ApiTreeItem{
-									Children: childrenJSON,
-								}
-
-								childKey, err := ExtractApiKeyFromTreeItem(apiName, []ApiTreeItem{tempItem})
-								if err == nil {
-									return childKey, nil
-								}
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-
-	return "", fmt.Errorf("在 API 树形列表中未找到名为 '%s' 的 API", apiName)
-}
-
 // ExtractMethodFromPath 从路径中提取 HTTP 方法
 func ExtractMethodFromPath(path string) string {
 	parts := strings.Split(path, " ")
@@ -459,8 +688,8 @@ func FormatCurrentTime() string {
 	return time.Now().Format("2006-01-02 15:04:05")
 }
 
-// analyzeJsonSchemaDiff 分析JSON Schema的变化并生成详细说明
-func analyzeJsonSchemaDiff(builder *strings.Builder, oldSchema, newSchema interface{}) error {
+// analyzeJsonSchemaDiff 分析JSON Schema的变化并生成详细说明，orders 为顶层字段在新旧结构中的顺序
+func analyzeJsonSchemaDiff(builder *diffWriter, oldSchema, newSchema interface{}, orders fieldOrders) error {
 	// 如果两者都为nil或者空字符串，则没有变化
 	if oldSchema == nil && newSchema == nil {
 		return nil
@@ -475,9 +704,9 @@ func analyzeJsonSchemaDiff(builder *strings.Builder, oldSchema, newSchema interf
 			// 分析属性变化
 			if newProps, ok := newMap["properties"].(map[string]interface{}); ok && len(newProps) > 0 {
 				// 因为是新增结构，所以直接显示所有字段
-				for propName, prop := range newProps {
+				for _, propName := range sortedKeys(newProps) {
 					propType := "object"
-					if propMap, ok := prop.(map[string]interface{}); ok {
+					if propMap, ok := newProps[propName].(map[string]interface{}); ok {
 						if t, ok := propMap["type"].(string); ok {
 							propType = t
 						}
@@ -516,7 +745,7 @@ func analyzeJsonSchemaDiff(builder *strings.Builder, oldSchema, newSchema interf
 	}
 
 	if oldSchema != nil && newSchema == nil {
-		builder.WriteString("* 移除了请求体结构\n")
+		builder.breakingf("* 移除了请求体结构\n")
 		return nil
 	}
 
@@ -529,7 +758,7 @@ func analyzeJsonSchemaDiff(builder *strings.Builder, oldSchema, newSchema interf
 		if oldType, ok := oldMap["type"].(string); ok {
 			if newType, ok := newMap["type"].(string); ok {
 				if oldType != newType {
-					builder.WriteString(fmt.Sprintf("* 数据类型: %s -> %s\n", oldType, newType))
+					builder.breakingf("* 数据类型: %s -> %s\n", oldType, newType)
 				}
 			}
 		}
@@ -537,8 +766,11 @@ func analyzeJsonSchemaDiff(builder *strings.Builder, oldSchema, newSchema interf
 		// 分析属性变化
 		if oldProps, ok := oldMap["properties"].(map[string]interface{}); ok {
 			if newProps, ok := newMap["properties"].(map[string]interface{}); ok {
-				// 比较属性
-				analyzePropertiesDiff(builder, oldProps, newProps)
+				// 比较属性，必填列表在父级 schema 上
+				oldRequired, _ := oldMap["required"].([]interface{})
+				newRequired, _ := newMap["required"].([]interface{})
+				analyzePropertiesDiff(builder, oldProps, newProps,
+					interfaceSliceToStringSlice(oldRequired), interfaceSliceToStringSlice(newRequired), orders)
 			}
 		}
 
@@ -576,7 +808,7 @@ func analyzeJsonSchemaDiff(builder *strings.Builder, oldSchema, newSchema interf
 								builder.WriteString("* 必填项变更:\n")
 								hasRequiredChanges = true
 							}
-							builder.WriteString(fmt.Sprintf("  + 新增必填: %s\n", field))
+							builder.requiredf("  + 新增必填: %s\n", field)
 						}
 					}
 
@@ -609,8 +841,9 @@ func analyzeJsonSchemaDiff(builder *strings.Builder, oldSchema, newSchema interf
 	return nil
 }
 
-// analyzePropertiesDiff 分析属性的变化
-func analyzePropertiesDiff(builder *strings.Builder, oldProps, newProps map[string]interface{}) {
+// analyzePropertiesDiff 分析属性的变化，oldRequired、newRequired 为父级 schema 的必填字段列表；
+// 已有字段的必填变化由父级单独列出，这里只用于标注删除、新增和重命名的字段
+func analyzePropertiesDiff(builder *diffWriter, oldProps, newProps map[string]interface{}, oldRequired, newRequired []string, orders fieldOrders) {
 	// 记录删除的字段（仅真正删除的字段，而非修改的字段）
 	var removedFields []string
 
@@ -619,42 +852,30 @@ func analyzePropertiesDiff(builder *strings.Builder, oldProps, newProps map[stri
 
 	// 记录修改的字段
 	var modifiedFields []struct {
-		name              string
-		oldType           string
-		newType           string
-		oldTitle          string
-		newTitle          string
-		oldDesc           string
-		newDesc           string
-		oldRequired       bool
-		newRequired       bool
-		hasRequiredChange bool
-		changes           map[string]struct{ old, new interface{} }
-	}
-
-	// 获取必填字段列表
-	oldRequiredFields := make(map[string]bool)
-	newRequiredFields := make(map[string]bool)
-
-	// 从父级schema获取required字段列表
-	if oldRequiredList, ok := oldProps["required"].([]interface{}); ok {
-		for _, field := range oldRequiredList {
-			if fieldName, ok := field.(string); ok {
-				oldRequiredFields[fieldName] = true
-			}
-		}
+		name     string
+		oldType  string
+		newType  string
+		oldTitle string
+		newTitle string
+		oldDesc  string
+		newDesc  string
+		changes  map[string]struct{ old, new interface{} }
 	}
 
-	if newRequiredList, ok := newProps["required"].([]interface{}); ok {
-		for _, field := range newRequiredList {
-			if fieldName, ok := field.(string); ok {
-				newRequiredFields[fieldName] = true
-			}
-		}
+	// 必填字段集合
+	oldRequiredFields := make(map[string]bool)
+	for _, field := range oldRequired {
+		oldRequiredFields[field] = true
+	}
+	newRequiredFields := make(map[string]bool)
+	for _, field := range newRequired {
+		newRequiredFields[field] = true
 	}
 
-	// 首先找出在两个集合中都存在的字段(可能被修改)和只在一个集合中存在的字段(新增或删除)
-	for propName, oldProp := range oldProps {
+	// 首先找出在两个集合中都存在的字段(可能被修改)和只在一个集合中存在的字段(新增或删除)，
+	// 按字段名排序保证输出顺序稳定
+	for _, propName := range sortedKeys(oldProps) {
+		oldProp := oldProps[propName]
 		if propName == "required" {
 			continue // 跳过required字段，它会在字段级别处理
 		}
@@ -664,12 +885,7 @@ func analyzePropertiesDiff(builder *strings.Builder, oldProps, newProps map[stri
 			oldPropJSON, _ := json.Marshal(oldProp)
 			newPropJSON, _ := json.Marshal(newProp)
 
-			// 检查必填状态变化
-			oldRequired := oldRequiredFields[propName]
-			newRequired := newRequiredFields[propName]
-			hasRequiredChange := oldRequired != newRequired
-
-			if !bytes.Equal(oldPropJSON, newPropJSON) || hasRequiredChange {
+			if !bytes.Equal(oldPropJSON, newPropJSON) {
 				// 检测到变化，这是一个修改的字段
 				var oldType, newType string
 				var oldTitle, newTitle string
@@ -718,17 +934,14 @@ func analyzePropertiesDiff(builder *strings.Builder, oldProps, newProps map[stri
 
 				// 添加到修改字段列表
 				modifiedFields = append(modifiedFields, struct {
-					name              string
-					oldType           string
-					newType           string
-					oldTitle          string
-					newTitle          string
-					oldDesc           string
-					newDesc           string
-					oldRequired       bool
-					newRequired       bool
-					hasRequiredChange bool
-					changes           map[string]struct{ old, new interface{} }
+					name     string
+					oldType  string
+					newType  string
+					oldTitle string
+					newTitle string
+					oldDesc  string
+					newDesc  string
+					changes  map[string]struct{ old, new interface{} }
 				}{
 					propName,
 					oldType,
@@ -737,9 +950,6 @@ func analyzePropertiesDiff(builder *strings.Builder, oldProps, newProps map[stri
 					newTitle,
 					oldDesc,
 					newDesc,
-					oldRequired,
-					newRequired,
-					hasRequiredChange,
 					changes,
 				})
 			}
@@ -750,7 +960,7 @@ func analyzePropertiesDiff(builder *strings.Builder, oldProps, newProps map[stri
 	}
 
 	// 找出真正新增的字段（只在新集合中存在）
-	for propName := range newProps {
+	for _, propName := range sortedKeys(newProps) {
 		if propName == "required" {
 			continue // 跳过required字段，它会在字段级别处理
 		}
@@ -760,11 +970,34 @@ func analyzePropertiesDiff(builder *strings.Builder, oldProps, newProps map[stri
 		}
 	}
 
+	// 删除和新增的字段中推测出的重命名，单独显示并标明为推测
+	renames := pairRenamedFields(oldProps, newProps, removedFields, addedFields, orders)
+	for _, rename := range renames {
+		removedFields = removeString(removedFields, rename.Old)
+		addedFields = removeString(addedFields, rename.New)
+
+		builder.breakingf("* 字段重命名: %s -> %s (推测)\n", rename.Old, rename.New)
+		oldPropMap, _ := oldProps[rename.Old].(map[string]interface{})
+		newPropMap, _ := newProps[rename.New].(map[string]interface{})
+		for _, key := range sortedKeys(mergeKeys(oldPropMap, newPropMap)) {
+			if !reflect.DeepEqual(oldPropMap[key], newPropMap[key]) {
+				builder.WriteString(fmt.Sprintf("  - %s: %s -> %s\n", key, formatValue(oldPropMap[key]), formatValue(newPropMap[key])))
+			}
+		}
+		if oldRequiredFields[rename.Old] != newRequiredFields[rename.New] {
+			if newRequiredFields[rename.New] {
+				builder.requiredf("  - 变为必填\n")
+			} else {
+				builder.WriteString("  - 变为可选\n")
+			}
+		}
+	}
+
 	// 先显示字段删除
 	if len(removedFields) > 0 {
 		for _, name := range removedFields {
 			oldProp := oldProps[name]
-			builder.WriteString(fmt.Sprintf("* 删除字段: %s", name))
+			builder.breakingf("* 删除字段: %s", name)
 
 			// 尝试添加类型信息
 			if oldPropMap, ok := oldProp.(map[string]interface{}); ok {
@@ -807,6 +1040,7 @@ func analyzePropertiesDiff(builder *strings.Builder, oldProps, newProps map[stri
 
 			// 添加必填信息
 			if newRequiredFields[name] {
+				builder.markRequired()
 				builder.WriteString(" (必填)")
 			}
 
@@ -826,7 +1060,7 @@ func analyzePropertiesDiff(builder *strings.Builder, oldProps, newProps map[stri
 
 			// 显示类型变化（如果有）
 			if field.oldType != field.newType && field.oldType != "" && field.newType != "" {
-				builder.WriteString(fmt.Sprintf("  - 类型: %s -> %s\n", field.oldType, field.newType))
+				builder.breakingf("  - 类型: %s -> %s\n", field.oldType, field.newType)
 			}
 
 			// 显示中文名称变化（如果有）
@@ -839,17 +1073,9 @@ func analyzePropertiesDiff(builder *strings.Builder, oldProps, newProps map[stri
 				builder.WriteString(fmt.Sprintf("  - 说明: %s -> %s\n", field.oldDesc, field.newDesc))
 			}
 
-			// 显示必填状态变化（如果有）
-			if field.hasRequiredChange {
-				if field.newRequired {
-					builder.WriteString("  - 变为必填\n")
-				} else {
-					builder.WriteString("  - 变为可选\n")
-				}
-			}
-
 			// 显示其他属性变化
-			for propName, change := range field.changes {
+			for _, propName := range sortedChangeKeys(field.changes) {
+				change := field.changes[propName]
 				// 跳过已单独处理的属性
 				if propName == "type" || propName == "title" || propName == "description" {
 					continue
@@ -867,6 +1093,211 @@ func analyzePropertiesDiff(builder *strings.Builder, oldProps, newProps map[stri
 	}
 }
 
+// fieldRename 推测出的字段重命名
+type fieldRename struct {
+	Old string
+	New string
+}
+
+// fieldOrders 字段在新旧结构中的顺序，缺少顺序信息时为 nil
+type fieldOrders struct {
+	Old []string
+	New []string
+}
+
+// schemaFieldOrder 读取 JSON Schema 中 x-apifox-orders 记录的顶层字段顺序
+func schemaFieldOrder(schema interface{}) []string {
+	schemaMap, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	orders, _ := schemaMap["x-apifox-orders"].([]interface{})
+	return interfaceSliceToStringSlice(orders)
+}
+
+// renamePositionScore 删除的字段在所有删除字段中的次序与新增的字段在所有新增字段中的次序相同时的加分，
+// 用于区分其他条件相同的候选
+const renamePositionScore = 10
+
+// pairRenamedFields 在删除和新增的字段之间推测重命名：类型必须相同，
+// 且名称只差大小写或分隔符、标题或说明相同、或名称编辑距离足够小（至少 5 个字符时）；
+// 满足条件的候选中位置对应的额外加分。每个字段最多参与一次配对，按得分从高到低贪心选择
+func pairRenamedFields(oldProps, newProps map[string]interface{}, removed, added []string, orders fieldOrders) []fieldRename {
+	type candidate struct {
+		rename fieldRename
+		score  int
+	}
+
+	oldRanks := fieldRanks(removed, orders.Old)
+	newRanks := fieldRanks(added, orders.New)
+
+	var candidates []candidate
+	for _, oldName := range removed {
+		oldProp, _ := oldProps[oldName].(map[string]interface{})
+		for _, newName := range added {
+			newProp, _ := newProps[newName].(map[string]interface{})
+			score, ok := renameScore(oldName, newName, oldProp, newProp)
+			if !ok {
+				continue
+			}
+			if oldRanks != nil && newRanks != nil && oldRanks[oldName] == newRanks[newName] {
+				score += renamePositionScore
+			}
+			candidates = append(candidates, candidate{fieldRename{oldName, newName}, score})
+		}
+	}
+
+	// 得分相同时按字段名排序，保证结果稳定
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		if candidates[i].rename.Old != candidates[j].rename.Old {
+			return candidates[i].rename.Old < candidates[j].rename.Old
+		}
+		return candidates[i].rename.New < candidates[j].rename.New
+	})
+
+	usedOld := make(map[string]bool)
+	usedNew := make(map[string]bool)
+	var renames []fieldRename
+	for _, c := range candidates {
+		if usedOld[c.rename.Old] || usedNew[c.rename.New] {
+			continue
+		}
+		usedOld[c.rename.Old] = true
+		usedNew[c.rename.New] = true
+		renames = append(renames, c.rename)
+	}
+	sort.Slice(renames, func(i, j int) bool { return renames[i].Old < renames[j].Old })
+	return renames
+}
+
+// responseFieldOrders 返回各状态码的响应结构中顶层字段的顺序
+func responseFieldOrders(oldResponses, newResponses []Response) map[int]fieldOrders {
+	orders := make(map[int]fieldOrders)
+	for _, resp := range oldResponses {
+		order := orders[resp.Code]
+		order.Old = schemaFieldOrder(resp.JsonSchema)
+		orders[resp.Code] = order
+	}
+	for _, resp := range newResponses {
+		order := orders[resp.Code]
+		order.New = schemaFieldOrder(resp.JsonSchema)
+		orders[resp.Code] = order
+	}
+	return orders
+}
+
+// fieldRanks 返回 names 中每个字段按 order 排列后的次序；order 未覆盖全部字段时返回 nil，不以位置作为依据
+func fieldRanks(names, order []string) map[string]int {
+	if len(order) == 0 {
+		return nil
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	ranks := make(map[string]int, len(names))
+	for _, name := range order {
+		if wanted[name] {
+			if _, seen := ranks[name]; !seen {
+				ranks[name] = len(ranks)
+			}
+		}
+	}
+	if len(ranks) != len(names) {
+		return nil
+	}
+	return ranks
+}
+
+// renameScore 评估两个字段是同一字段改名的可能性，ok 为 false 表示不可能
+func renameScore(oldName, newName string, oldProp, newProp map[string]interface{}) (score int, ok bool) {
+	oldType, _ := oldProp["type"].(string)
+	newType, _ := newProp["type"].(string)
+	if oldType != newType {
+		return 0, false
+	}
+
+	// 只差大小写或分隔符，如 userName -> username、user_name
+	if canonicalFieldName(oldName) == canonicalFieldName(newName) {
+		score += 100
+	}
+	if title, _ := oldProp["title"].(string); title != "" && title == newProp["title"] {
+		score += 50
+	}
+	if desc, _ := oldProp["description"].(string); desc != "" && desc == newProp["description"] {
+		score += 50
+	}
+
+	distance := editDistance(strings.ToLower(oldName), strings.ToLower(newName))
+	maxLen := len([]rune(oldName))
+	if n := len([]rune(newName)); n > maxLen {
+		maxLen = n
+	}
+	// 名称过短时编辑距离没有参考意义，如 name 与 game
+	similar := maxLen >= 5 && distance <= maxLen/3
+	if similar {
+		score += 30 - distance
+	}
+
+	if score == 0 || (!similar && score < 50) {
+		return 0, false
+	}
+	return score, true
+}
+
+// canonicalFieldName 去掉分隔符并转为小写
+func canonicalFieldName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
+
+// editDistance 计算两个字符串的编辑距离
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// mergeKeys 返回两个 map 的键的并集
+func mergeKeys(a, b map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(a)+len(b))
+	for k := range a {
+		merged[k] = nil
+	}
+	for k := range b {
+		merged[k] = nil
+	}
+	return merged
+}
+
+// removeString 返回去掉指定元素后的切片
+func removeString(slice []string, item string) []string {
+	var result []string
+	for _, s := range slice {
+		if s != item {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
 // formatValue 将值格式化为字符串
 func formatValue(v interface{}) string {
 	if v == nil {
@@ -891,6 +1322,26 @@ func formatValue(v interface{}) string {
 	}
 }
 
+// sortedKeys 返回按字典序排列的键
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedChangeKeys 返回按字典序排列的属性名
+func sortedChangeKeys(changes map[string]struct{ old, new interface{} }) []string {
+	keys := make([]string, 0, len(changes))
+	for k := range changes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // contains 检查字符串切片是否包含特定字符串
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -0,0 +1,100 @@
+package apifox_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/internal/apifox"
+	"github.com/xhy/api-pulse/internal/dingtalk"
+)
+
+// update 为 true 时用当前输出覆盖 golden 文件：go test ./internal/apifox -run TestCompareApisGolden -update
+var update = flag.Bool("update", false, "用当前输出覆盖 golden 文件")
+
+// goldenDir 差异用例目录，每个子目录包含 old.json、new.json 以及期望的 diff.golden.json、notify.golden.md
+const goldenDir = "testdata/diff"
+
+func newDiffService() *apifox.DiffService {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return apifox.NewDiffService(logger)
+}
+
+// readDetail 读取用例中的 API 详情
+func readDetail(t *testing.T, path string) apifox.ApiDetail {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var detail apifox.ApiDetail
+	if err := json.Unmarshal(data, &detail); err != nil {
+		t.Fatalf("解析 %s: %v", path, err)
+	}
+	return detail
+}
+
+// compareGolden 将输出与 golden 文件比较，-update 时改为写入
+func compareGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取 golden 文件失败（可使用 -update 生成）: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s 不匹配\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
+
+// TestCompareApisGolden 逐个用例比较结构化差异与渲染后的通知正文
+func TestCompareApisGolden(t *testing.T) {
+	cases, err := os.ReadDir(goldenDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := newDiffService()
+	for _, c := range cases {
+		if !c.IsDir() {
+			continue
+		}
+		dir := filepath.Join(goldenDir, c.Name())
+
+		t.Run(c.Name(), func(t *testing.T) {
+			oldApi := readDetail(t, filepath.Join(dir, "old.json"))
+			newApi := readDetail(t, filepath.Join(dir, "new.json"))
+
+			diff := service.CompareApis(oldApi, newApi, "张三", "2024-05-01 10:00:00")
+
+			structured, err := json.MarshalIndent(diff, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareGolden(t, filepath.Join(dir, "diff.golden.json"), append(structured, '\n'))
+			compareGolden(t, filepath.Join(dir, "notify.golden.md"), []byte(dingtalk.RenderApiDiffMarkdown(*diff)))
+
+			// map 遍历顺序随机，多次比较的输出必须完全一致
+			for i := 0; i < 20; i++ {
+				again, _ := json.MarshalIndent(service.CompareApis(oldApi, newApi, "张三", "2024-05-01 10:00:00"), "", "  ")
+				if !bytes.Equal(again, structured) {
+					t.Fatalf("第 %d 次比较的输出与首次不同:\n%s\n---\n%s", i+2, again, structured)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package apifox
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/xhy/api-pulse/config"
+)
+
+// ignoreRule 编译后的忽略规则
+type ignoreRule struct {
+	path      string
+	segments  []string
+	apiIDs    map[int]bool
+	folderIDs map[int]bool
+}
+
+// compileIgnoreRule 拆分路径：不含 "." 的名称视为 "**.<名称>"，结尾的 "**" 与去掉它等价
+func compileIgnoreRule(rule config.IgnoreRule) ignoreRule {
+	compiled := ignoreRule{path: rule.Path}
+
+	segments := strings.Split(strings.Trim(rule.Path, "."), ".")
+	if len(segments) == 1 && segments[0] != "**" {
+		segments = []string{"**", segments[0]}
+	}
+	for len(segments) > 0 && segments[len(segments)-1] == "**" {
+		segments = segments[:len(segments)-1]
+	}
+	compiled.segments = segments
+
+	if len(rule.ApiIDs) > 0 {
+		compiled.apiIDs = make(map[int]bool, len(rule.ApiIDs))
+		for _, id := range rule.ApiIDs {
+			compiled.apiIDs[id] = true
+		}
+	}
+	if len(rule.FolderIDs) > 0 {
+		compiled.folderIDs = make(map[int]bool, len(rule.FolderIDs))
+		for _, id := range rule.FolderIDs {
+			compiled.folderIDs[id] = true
+		}
+	}
+	return compiled
+}
+
+// appliesTo 规则是否对该 API 生效，接口移动目录时新旧目录任一命中即生效
+func (r ignoreRule) appliesTo(oldApi, newApi ApiDetail) bool {
+	if r.apiIDs == nil && r.folderIDs == nil {
+		return true
+	}
+	return r.apiIDs[newApi.ID] || r.folderIDs[oldApi.FolderID] || r.folderIDs[newApi.FolderID]
+}
+
+// applyIgnoreRules 从 API 详情中移除命中规则的内容，比较双方都移除后这些内容不会产生差异
+func applyIgnoreRules(detail ApiDetail, rules []ignoreRule) ApiDetail {
+	if len(rules) == 0 {
+		return detail
+	}
+
+	data, err := json.Marshal(detail)
+	if err != nil {
+		return detail
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return detail
+	}
+
+	for _, rule := range rules {
+		if len(rule.segments) > 0 {
+			value = pruneMatches(value, rule.segments, false)
+		}
+	}
+
+	data, err = json.Marshal(value)
+	if err != nil {
+		return detail
+	}
+	var pruned ApiDetail
+	if err := json.Unmarshal(data, &pruned); err != nil {
+		return detail
+	}
+	return pruned
+}
+
+// pruneMatches 移除 value 下与 segments 匹配的字段或数组元素，返回处理后的值；
+// fieldNames 为 true 表示 value 是 JSON Schema 的 properties，其键为字段名而不是属性名
+func pruneMatches(value interface{}, segments []string, fieldNames bool) interface{} {
+	segment, rest := segments[0], segments[1:]
+
+	// "**" 先按匹配零层处理，再带着完整的 segments 进入每个子节点；
+	// properties 的键是接口字段，"**" 只穿过它们，不把字段当作删除目标，避免同名字段的增删被忽略
+	if segment == "**" {
+		if len(rest) > 0 && !fieldNames {
+			value = pruneMatches(value, rest, false)
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, child := range v {
+				v[key] = pruneMatches(child, segments, !fieldNames && key == "properties")
+			}
+		case []interface{}:
+			for i, child := range v {
+				v[i] = pruneMatches(child, segments, false)
+			}
+		}
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if segment != "*" && segment != key {
+				continue
+			}
+			if len(rest) == 0 {
+				delete(v, key)
+			} else {
+				v[key] = pruneMatches(child, rest, !fieldNames && key == "properties")
+			}
+		}
+	case []interface{}:
+		kept := v[:0]
+		for i, child := range v {
+			if segment != "*" && segment != strconv.Itoa(i) {
+				kept = append(kept, child)
+				continue
+			}
+			if len(rest) > 0 {
+				kept = append(kept, pruneMatches(child, rest, false))
+			}
+		}
+		return kept
+	}
+	return value
+}
@@ -0,0 +1,109 @@
+package apifox_test
+
+import (
+	"testing"
+
+	"github.com/xhy/api-pulse/config"
+	"github.com/xhy/api-pulse/internal/apifox"
+)
+
+// describedApi 请求体、响应结构和查询参数中都带有描述的示例 API，结构中还有一个名为 description 的字段
+func describedApi(description string) apifox.ApiDetail {
+	schema := func() map[string]interface{} {
+		return map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name":        map[string]interface{}{"type": "string", "description": description},
+				"description": map[string]interface{}{"type": "string"},
+			},
+		}
+	}
+	return apifox.ApiDetail{
+		ID:       1,
+		Name:     "更新用户",
+		Method:   "put",
+		Path:     "/users/{id}",
+		FolderID: 10,
+		RequestBody: apifox.RequestBody{
+			Type:       "application/json",
+			JsonSchema: schema(),
+		},
+		Parameters: apifox.Parameters{
+			Query: []apifox.Parameter{{Name: "notify", Type: "boolean", Description: description, Enable: true}},
+		},
+		Responses: []apifox.Response{{ID: 1, Name: "成功", Code: 200, ContentType: "json", JsonSchema: schema()}},
+		ResponseExamples: []apifox.ResponseExample{
+			{ID: 1, ResponseID: 1, Name: "成功示例", Data: `{"name":"` + description + `"}`},
+		},
+	}
+}
+
+// TestIgnoreRules 忽略规则命中的内容不产生差异，未命中的照常比较
+func TestIgnoreRules(t *testing.T) {
+	oldApi, newApi := describedApi("旧描述"), describedApi("新描述")
+
+	tests := []struct {
+		name                         string
+		rules                        []config.IgnoreRule
+		update                       func(detail *apifox.ApiDetail) // 在新版本上额外做的修改
+		body, params, responses, any bool
+	}{
+		{
+			name: "无规则",
+			body: true, params: true, responses: true, any: true,
+		},
+		{
+			name:  "字段名匹配任意层级",
+			rules: []config.IgnoreRule{{Path: "description"}, {Path: "responseExamples"}},
+		},
+		{
+			name:  "只忽略响应结构中的描述",
+			rules: []config.IgnoreRule{{Path: "responses.*.jsonSchema.**.description"}},
+			body:  true, params: true, responses: true, any: true,
+		},
+		{
+			name:      "忽略整个部分",
+			rules:     []config.IgnoreRule{{Path: "requestBody"}, {Path: "parameters.query.*.description"}},
+			responses: true, any: true,
+		},
+		{
+			name:  "限定其他 API 时不生效",
+			rules: []config.IgnoreRule{{Path: "description", ApiIDs: []int{2}}},
+			body:  true, params: true, responses: true, any: true,
+		},
+		{
+			name:  "限定所在目录时生效",
+			rules: []config.IgnoreRule{{Path: "description", FolderIDs: []int{10}}, {Path: "responseExamples.*.data", ApiIDs: []int{1}}},
+		},
+		{
+			name:  "不忽略与规则同名的字段",
+			rules: []config.IgnoreRule{{Path: "description"}, {Path: "responseExamples"}},
+			update: func(detail *apifox.ApiDetail) {
+				delete(detail.RequestBody.JsonSchema.(map[string]interface{})["properties"].(map[string]interface{}), "description")
+			},
+			body: true, any: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldApi, newApi := oldApi, newApi
+			if tt.update != nil {
+				oldApi, newApi = describedApi("旧描述"), describedApi("新描述")
+				tt.update(&newApi)
+			}
+
+			service := newDiffService()
+			service.SetIgnoreRules(tt.rules)
+
+			diff := service.CompareApis(oldApi, newApi, "张三", "2024-05-01 10:00:00")
+			if diff.RequestBodyDiff != tt.body || diff.ParametersDiff != tt.params || diff.HasChanges() != tt.any {
+				t.Errorf("body=%v params=%v any=%v, want body=%v params=%v any=%v\n%+v",
+					diff.RequestBodyDiff, diff.ParametersDiff, diff.HasChanges(), tt.body, tt.params, tt.any, diff)
+			}
+			if diff.ResponsesDiff != tt.responses {
+				t.Errorf("responses=%v, want %v: %q", diff.ResponsesDiff, tt.responses, diff.ResponsesDetail)
+			}
+		})
+	}
+}
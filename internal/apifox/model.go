@@ -6,18 +6,8 @@ import (
 
 // ApiTreeListResponse API树形列表响应结构
 type ApiTreeListResponse struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data"`
-}
-
-// ApiTreeItem API树形列表项
-type ApiTreeItem struct {
-	Key      string          `json:"key"`
-	Type     string          `json:"type"`
-	Name     string          `json:"name"`
-	Children json.RawMessage `json:"children"`
-	Api      *ApiBasic       `json:"api,omitempty"`
-	Folder   *ApiFolder      `json:"folder,omitempty"`
+	Success bool    `json:"success"`
+	Data    ApiTree `json:"data"`
 }
 
 // ApiFolder API文件夹信息
@@ -47,6 +37,7 @@ type ApiBasic struct {
 	CustomApiFields interface{} `json:"customApiFields"`
 	Visibility      string      `json:"visibility"`
 	EditorID        int         `json:"editorId"`
+	UpdatedAt       string      `json:"updatedAt,omitempty"`
 }
 
 // ApiDetailResponse API详细信息响应结构
@@ -57,41 +48,65 @@ type ApiDetailResponse struct {
 
 // ApiDetail API详细信息
 type ApiDetail struct {
-	ID               int              `json:"id"`
-	Name             string           `json:"name"`
-	Type             string           `json:"type"`
-	Method           string           `json:"method"`
-	Path             string           `json:"path"`
-	Description      string           `json:"description"`
-	Status           string           `json:"status"`
-	RequestBody      RequestBody      `json:"requestBody"`
-	Parameters       Parameters       `json:"parameters"`
-	Responses        []Response       `json:"responses"`
-	FolderID         int              `json:"folderId"`
-	Tags             []string         `json:"tags"`
-	CreatedAt        string           `json:"createdAt"`
-	UpdatedAt        string           `json:"updatedAt"`
-	CreatorID        int              `json:"creatorId"`
-	EditorID         int              `json:"editorId"`
-	OperationID      string           `json:"operationId"`
-	CommonParameters CommonParameters `json:"commonParameters"`
-	Visibility       string           `json:"visibility"`
-	ResponsibleID    int              `json:"responsibleId"`
+	ID               int               `json:"id"`
+	Name             string            `json:"name"`
+	Type             string            `json:"type"`
+	Method           string            `json:"method"`
+	Path             string            `json:"path"`
+	Description      string            `json:"description"`
+	Status           string            `json:"status"`
+	RequestBody      RequestBody       `json:"requestBody"`
+	Parameters       Parameters        `json:"parameters"`
+	Responses        []Response        `json:"responses"`
+	FolderID         int               `json:"folderId"`
+	Tags             []string          `json:"tags"`
+	CreatedAt        string            `json:"createdAt"`
+	UpdatedAt        string            `json:"updatedAt"`
+	CreatorID        int               `json:"creatorId"`
+	EditorID         int               `json:"editorId"`
+	OperationID      string            `json:"operationId"`
+	CommonParameters CommonParameters  `json:"commonParameters"`
+	Visibility       string            `json:"visibility"`
+	ResponsibleID    int               `json:"responsibleId"`
+	ResponseExamples []ResponseExample `json:"responseExamples"`
+	// Auth 接口级的鉴权设置，结构随鉴权类型变化，原样保存
+	Auth interface{} `json:"auth"`
+
+	// Raw Apifox 返回的原始 JSON，保存未单独建模的字段，不参与差异比较
+	Raw json.RawMessage `json:"raw,omitempty"`
 }
 
 // RequestBody 请求体
 type RequestBody struct {
-	Type       string        `json:"type"`
-	Parameters []Parameter   `json:"parameters"`
-	JsonSchema interface{}   `json:"jsonSchema"`
-	MediaType  string        `json:"mediaType"`
-	Examples   []interface{} `json:"examples"`
+	Type       string      `json:"type"`
+	Parameters []Parameter `json:"parameters"`
+	JsonSchema interface{} `json:"jsonSchema"`
+	MediaType  string      `json:"mediaType"`
+	Examples   []Example   `json:"examples"`
+}
+
+// Example 请求体示例
+type Example struct {
+	Name        string      `json:"name,omitempty"`
+	MediaType   string      `json:"mediaType,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Value       interface{} `json:"value"`
+}
+
+// ResponseExample 响应示例，ResponseID 对应 Response.ID
+type ResponseExample struct {
+	ID         int    `json:"id"`
+	ResponseID int    `json:"responseId"`
+	Name       string `json:"name"`
+	Data       string `json:"data"`
 }
 
 // Parameters 参数信息
 type Parameters struct {
-	Query []Parameter `json:"query"`
-	Path  []Parameter `json:"path"`
+	Query  []Parameter `json:"query"`
+	Path   []Parameter `json:"path"`
+	Header []Parameter `json:"header"`
+	Cookie []Parameter `json:"cookie"`
 }
 
 // Parameter 参数详情
@@ -102,17 +117,19 @@ type Parameter struct {
 	Description string `json:"description"`
 	Type        string `json:"type"`
 	Enable      bool   `json:"enable"`
+	// Example 参数示例值，Apifox 中可能是字符串、数字或数组
+	Example interface{} `json:"example,omitempty"`
 }
 
 // Response 响应详情
 type Response struct {
-	ID          int           `json:"id"`
-	Name        string        `json:"name"`
-	Code        int           `json:"code"`
-	ContentType string        `json:"contentType"`
-	JsonSchema  interface{}   `json:"jsonSchema"`
-	Description string        `json:"description"`
-	Headers     []interface{} `json:"headers"`
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	Code        int         `json:"code"`
+	ContentType string      `json:"contentType"`
+	JsonSchema  interface{} `json:"jsonSchema"`
+	Description string      `json:"description"`
+	Headers     []Parameter `json:"headers"`
 }
 
 // CommonParameters 通用参数
@@ -120,12 +137,7 @@ type CommonParameters struct {
 	Query  []interface{} `json:"query"`
 	Body   []interface{} `json:"body"`
 	Cookie []interface{} `json:"cookie"`
-	Header []HeaderParam `json:"header"`
-}
-
-// HeaderParam 头部参数
-type HeaderParam struct {
-	Name string `json:"name"`
+	Header []Parameter   `json:"header"`
 }
 
 // WebhookPayload 接收到的Webhook请求体
@@ -144,6 +156,82 @@ type StoredApiInfo struct {
 	Method    string    `json:"method"`
 	Detail    ApiDetail `json:"detail"`
 	UpdatedAt string    `json:"updated_at"`
+
+	// Incomplete 基线不完整（详情获取失败，仅有基本信息），不参与差异比较
+	Incomplete bool `json:"incomplete,omitempty"`
+	// TreeFingerprint 获取详情时树形列表元数据的指纹，增量同步据此判断是否需要重新获取
+	TreeFingerprint string `json:"tree_fingerprint,omitempty"`
+}
+
+// ApiVersion API 快照的一个历史版本
+type ApiVersion struct {
+	Version int       `json:"version"`
+	SavedAt string    `json:"saved_at"`
+	Detail  ApiDetail `json:"detail"`
+}
+
+// 变更来源
+const (
+	SourceWebhook = "webhook"
+	SourceSync    = "sync"
+)
+
+// 变更严重程度
+const (
+	SeverityHigh   = "high"   // 可能破坏调用方：删除或废弃接口、方法/路径或鉴权变更、删除字段、必填或类型变化
+	SeverityMedium = "medium" // 其他请求/响应结构变化
+	SeverityLow    = "low"    // 新增接口等不影响现有调用的变化
+)
+
+// ChangeRecord 一条 API 变更历史记录
+type ChangeRecord struct {
+	ID            int64    `json:"id"`
+	ApiKey        string   `json:"api_key"`
+	ApiID         int      `json:"api_id"`
+	Name          string   `json:"name"`
+	Method        string   `json:"method"`
+	Path          string   `json:"path"`
+	FolderID      int      `json:"folder_id"`
+	ResponsibleID int      `json:"responsible_id"`
+	ChangeType    string   `json:"change_type"`
+	Severity      string   `json:"severity"`
+	Source        string   `json:"source"`
+	ModifierName  string   `json:"modifier_name"`
+	ModifiedTime  string   `json:"modified_time"`
+	RecordedAt    string   `json:"recorded_at"`
+	FromVersion   int      `json:"from_version,omitempty"`
+	ToVersion     int      `json:"to_version,omitempty"`
+	Diff          *ApiDiff `json:"diff,omitempty"`
+}
+
+// Digest 一段时间内变更历史的摘要
+type Digest struct {
+	ProjectID     string           `json:"project_id"`
+	ResponsibleID int              `json:"responsible_id,omitempty"`
+	Since         string           `json:"since"`
+	Until         string           `json:"until"`
+	Total         int              `json:"total"`
+	ByType        map[string]int   `json:"by_type"`
+	BySeverity    map[string]int   `json:"by_severity"`
+	Apis          []DigestApi      `json:"apis"`
+	TopModifiers  []DigestModifier `json:"top_modifiers"`
+}
+
+// DigestApi 摘要中受影响的接口，Severity 为期间最高的严重程度，ChangeTypes 按首次出现的顺序
+type DigestApi struct {
+	ApiKey      string   `json:"api_key"`
+	Name        string   `json:"name"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Changes     int      `json:"changes"`
+	Severity    string   `json:"severity"`
+	ChangeTypes []string `json:"change_types"`
+}
+
+// DigestModifier 摘要中的修改者及其变更次数
+type DigestModifier struct {
+	Name    string `json:"name"`
+	Changes int    `json:"changes"`
 }
 
 // ApiDiff API差异信息
@@ -158,6 +246,9 @@ type ApiDiff struct {
 	PathDiff   bool   `json:"path_diff"`
 	MethodDiff bool   `json:"method_diff"`
 
+	// PathParamRenames 路径只有参数名变化（如 {id} -> {userId}）时的重命名列表，此时 PathDiff 为 false
+	PathParamRenames []PathParamRename `json:"path_param_renames,omitempty"`
+
 	RequestBodyDiff   bool   `json:"request_body_diff"`
 	RequestBodyDetail string `json:"request_body_detail,omitempty"`
 
@@ -167,7 +258,53 @@ type ApiDiff struct {
 	ResponsesDiff   bool   `json:"responses_diff"`
 	ResponsesDetail string `json:"responses_detail,omitempty"`
 
+	// Breaking 请求体、参数或响应中有可能破坏调用方的变更，如删除、类型变化、变为必填、新增必填项或字段重命名
+	Breaking bool `json:"breaking,omitempty"`
+
+	AuthDiff   bool   `json:"auth_diff"`
+	AuthDetail string `json:"auth_detail,omitempty"`
+
+	// MetadataDiff 名称、状态、描述、标签、目录、可见性或负责人变化
+	MetadataDiff   bool   `json:"metadata_diff"`
+	MetadataDetail string `json:"metadata_detail,omitempty"`
+
+	// 状态变化时的新旧状态，Deprecated 表示由其他状态变为将废弃或已废弃
+	OldStatus  string `json:"old_status,omitempty"`
+	NewStatus  string `json:"new_status,omitempty"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+
+	// 负责人变化时的新旧负责人，Handover 表示接口交接给了其他负责人
+	OldResponsibleID int  `json:"old_responsible_id,omitempty"`
+	NewResponsibleID int  `json:"new_responsible_id,omitempty"`
+	Handover         bool `json:"handover,omitempty"`
+
 	ModifierName string `json:"modifier_name"`
 	ModifiedTime string `json:"modified_time"`
 	IsNewApi     bool   `json:"is_new_api"`
+	IsDeleted    bool   `json:"is_deleted"`
+}
+
+// ResourceEvent 非接口资源（文档、数据模型、目录、测试用例、分支）的变更事件
+type ResourceEvent struct {
+	Event        string            `json:"event"`
+	Category     string            `json:"category"`
+	Action       string            `json:"action"`
+	Title        string            `json:"title"`
+	Name         string            `json:"name"`
+	ModifierName string            `json:"modifier_name"`
+	ModifiedTime string            `json:"modified_time"`
+	Fields       map[string]string `json:"fields,omitempty"`
+}
+
+// WebhookEventRecord 未能识别的 Webhook 事件记录，保存以便排查
+type WebhookEventRecord struct {
+	Event      string `json:"event"`
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	ReceivedAt string `json:"received_at"`
+}
+
+// HasChanges 是否存在需要通知的实质性变更
+func (d *ApiDiff) HasChanges() bool {
+	return d.PathDiff || d.MethodDiff || d.RequestBodyDiff || d.ParametersDiff || d.ResponsesDiff || d.AuthDiff || d.MetadataDiff
 }
@@ -0,0 +1,281 @@
+package apifox
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// volatileSchemaKeys JSON Schema 中只影响展示、每次保存都可能重新生成的字段，比较前移除
+var volatileSchemaKeys = map[string]bool{
+	"x-apifox-orders":            true,
+	"x-apifox-ignore-properties": true,
+}
+
+// emptySchemaKeys Apifox 保存时会补上的 JSON Schema 关键字，值为空时与不存在等价；
+// 以 "x-apifox-" 开头的扩展字段同样处理
+var emptySchemaKeys = map[string]bool{
+	"properties": true,
+	"required":   true,
+	"examples":   true,
+	"enum":       true,
+}
+
+// schemaDataKeys JSON Schema 中值为示例数据而不是结构定义的关键字，其中的内容原样保留
+var schemaDataKeys = map[string]bool{
+	"default":  true,
+	"example":  true,
+	"examples": true,
+	"enum":     true,
+	"const":    true,
+}
+
+// Normalize 返回用于比较的规范化 API 详情：参数按名称排序、去掉每次保存都会重新生成的 ID 和时间戳、
+// 顶层的 nil 与空集合统一为 nil、自由结构（JSON Schema、示例、鉴权）统一为按键排序的 map；
+// JSON Schema 中只去掉 Apifox 补上的空关键字，字段定义和示例数据中的空值原样保留；
+// 结果只用于比较，不应写回存储
+func Normalize(detail ApiDetail) ApiDetail {
+	detail.CreatedAt = ""
+	detail.UpdatedAt = ""
+	detail.CreatorID = 0
+	detail.EditorID = 0
+	detail.Raw = nil
+
+	detail.Tags = normalizeTags(detail.Tags)
+
+	detail.RequestBody.Parameters = normalizeParameters(detail.RequestBody.Parameters)
+	detail.RequestBody.JsonSchema = canonicalSchema(detail.RequestBody.JsonSchema)
+	detail.RequestBody.Examples = normalizeExamples(detail.RequestBody.Examples)
+
+	detail.Parameters.Query = normalizeParameters(detail.Parameters.Query)
+	detail.Parameters.Path = normalizeParameters(detail.Parameters.Path)
+	detail.Parameters.Header = normalizeParameters(detail.Parameters.Header)
+	detail.Parameters.Cookie = normalizeParameters(detail.Parameters.Cookie)
+
+	detail.CommonParameters = normalizeCommonParameters(detail.CommonParameters)
+
+	// 响应示例通过响应 ID 关联响应，响应 ID 会重新生成，改为以状态码关联
+	responseCodes := make(map[int]int, len(detail.Responses))
+	for _, resp := range detail.Responses {
+		responseCodes[resp.ID] = resp.Code
+	}
+	detail.Responses = normalizeResponses(detail.Responses)
+	detail.ResponseExamples = normalizeResponseExamples(detail.ResponseExamples, responseCodes)
+
+	detail.Auth = canonicalValue(detail.Auth)
+
+	return detail
+}
+
+// normalizeTags 排序标签，空列表返回 nil
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// normalizeParameters 去掉参数 ID 并按名称排序，空列表返回 nil
+func normalizeParameters(params []Parameter) []Parameter {
+	if len(params) == 0 {
+		return nil
+	}
+	normalized := make([]Parameter, len(params))
+	for i, p := range params {
+		p.ID = ""
+		p.Example = canonicalValue(p.Example)
+		normalized[i] = p
+	}
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return normalized[i].Name < normalized[j].Name
+	})
+	return normalized
+}
+
+// normalizeResponses 去掉响应 ID、规范化响应头和响应结构，并按状态码排序
+func normalizeResponses(responses []Response) []Response {
+	if len(responses) == 0 {
+		return nil
+	}
+	normalized := make([]Response, len(responses))
+	for i, resp := range responses {
+		resp.ID = 0
+		resp.Headers = normalizeParameters(resp.Headers)
+		resp.JsonSchema = canonicalSchema(resp.JsonSchema)
+		normalized[i] = resp
+	}
+	sort.SliceStable(normalized, func(i, j int) bool {
+		if normalized[i].Code != normalized[j].Code {
+			return normalized[i].Code < normalized[j].Code
+		}
+		return normalized[i].Name < normalized[j].Name
+	})
+	return normalized
+}
+
+// normalizeExamples 规范化请求示例的值，空列表返回 nil
+func normalizeExamples(examples []Example) []Example {
+	if len(examples) == 0 {
+		return nil
+	}
+	normalized := make([]Example, len(examples))
+	for i, e := range examples {
+		e.Value = canonicalValue(e.Value)
+		normalized[i] = e
+	}
+	return normalized
+}
+
+// normalizeResponseExamples 去掉示例 ID，ResponseID 换成对应响应的状态码，示例内容为 JSON 时统一格式，按名称排序
+func normalizeResponseExamples(examples []ResponseExample, responseCodes map[int]int) []ResponseExample {
+	if len(examples) == 0 {
+		return nil
+	}
+	normalized := make([]ResponseExample, len(examples))
+	for i, e := range examples {
+		e.ID = 0
+		e.ResponseID = responseCodes[e.ResponseID]
+		e.Data = canonicalJSONString(e.Data)
+		normalized[i] = e
+	}
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return normalized[i].Name < normalized[j].Name
+	})
+	return normalized
+}
+
+// normalizeCommonParameters 规范化公共参数引用，按名称排序
+func normalizeCommonParameters(common CommonParameters) CommonParameters {
+	return CommonParameters{
+		Query:  normalizeCommonList(common.Query),
+		Body:   normalizeCommonList(common.Body),
+		Cookie: normalizeCommonList(common.Cookie),
+		Header: normalizeParameters(common.Header),
+	}
+}
+
+// normalizeCommonList 规范化一组公共参数引用，按名称排序
+func normalizeCommonList(list []interface{}) []interface{} {
+	if len(list) == 0 {
+		return nil
+	}
+	normalized := make([]interface{}, len(list))
+	for i, item := range list {
+		normalized[i] = canonicalValue(item)
+	}
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return commonParameterName(normalized[i]) < commonParameterName(normalized[j])
+	})
+	return normalized
+}
+
+// commonParameterName 返回公共参数引用的名称
+func commonParameterName(item interface{}) string {
+	if m, ok := item.(map[string]interface{}); ok {
+		if name, ok := m["name"].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// canonicalValue 将自由结构统一为 JSON 解码后的形式（map[string]interface{}、[]interface{} 等），
+// 整体为空 map、空数组时统一为 nil，内部的内容原样保留
+func canonicalValue(value interface{}) interface{} {
+	value = decodedValue(value)
+	if isEmptyValue(value) {
+		return nil
+	}
+	return value
+}
+
+// canonicalSchema 规范化 JSON Schema：移除 volatileSchemaKeys 中的字段，以及值为空的 emptySchemaKeys 和 "x-apifox-" 扩展字段；
+// properties 中的字段即使定义为空（任意类型）也保留，示例数据原样保留，整体为空时返回 nil
+func canonicalSchema(schema interface{}) interface{} {
+	schema = normalizeSchemaNode(decodedValue(schema))
+	if isEmptyValue(schema) {
+		return nil
+	}
+	return schema
+}
+
+// normalizeSchemaNode 就地规范化 JSON Schema 中的一个节点，value 须为 decodedValue 返回的副本
+func normalizeSchemaNode(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			switch {
+			case volatileSchemaKeys[key]:
+				delete(v, key)
+				continue
+			case schemaDataKeys[key]:
+				// 示例数据不是结构定义，不做处理
+			case key == "properties":
+				// 键为字段名，不能当作关键字处理
+				if properties, ok := item.(map[string]interface{}); ok {
+					for name, property := range properties {
+						properties[name] = normalizeSchemaNode(property)
+					}
+				}
+			default:
+				item = normalizeSchemaNode(item)
+				v[key] = item
+			}
+			if (emptySchemaKeys[key] || strings.HasPrefix(key, "x-apifox-")) && isEmptyValue(item) {
+				delete(v, key)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeSchemaNode(item)
+		}
+	}
+	return value
+}
+
+// decodedValue 经过一次编码解码返回副本，使结构体与 map 表示的同一内容得到相同的形式
+func decodedValue(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, string, float64, bool:
+		return value
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+// isEmptyValue 是否为 nil、空 map 或空数组
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// canonicalJSONString 内容为 JSON 时返回紧凑、按键排序的形式，否则去掉首尾空白后原样返回；
+// 空对象、空数组等内容原样保留
+func canonicalJSONString(s string) string {
+	trimmed := strings.TrimSpace(s)
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(trimmed), &decoded); err != nil {
+		return trimmed
+	}
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return trimmed
+	}
+	return string(data)
+}
@@ -0,0 +1,690 @@
+package apifox
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/config"
+)
+
+// exportCacheTTL 单独获取详情时复用上次导出结果的时长；树形列表和映射总是重新导出
+const exportCacheTTL = time.Minute
+
+// exportMissInterval 导出结果中找不到接口时，只有上次导出早于该时长才重新导出，
+// 避免反复查找已删除的接口时每次都触发全量导出
+const exportMissInterval = 10 * time.Second
+
+// operationMethods OpenAPI 路径项中表示操作的键
+var operationMethods = []string{"get", "post", "put", "delete", "patch", "head", "options", "trace"}
+
+// runInApifoxPattern 从 x-run-in-apifox 链接中提取接口 ID，如 ".../apis/api-12345-run"
+var runInApifoxPattern = regexp.MustCompile(`api-(\d+)`)
+
+// OpenAPIClient 通过 Apifox 官方 Open API 导出 OpenAPI 文档获取接口信息，使用个人访问令牌鉴权；
+// 一次导出即包含项目全部接口，树形列表、映射和详情都由导出结果转换而来
+type OpenAPIClient struct {
+	*authTracker
+
+	config     *config.ApifoxConfig
+	httpClient *resty.Client
+	logger     *logrus.Logger
+
+	fetchMutex    sync.Mutex // 同一时间只进行一次导出
+	snapshotMutex sync.Mutex
+	snapshot      *exportSnapshot
+}
+
+// exportSnapshot 一次导出的转换结果
+type exportSnapshot struct {
+	fetchedAt time.Time     // 开始导出的时间，结果不早于该时刻
+	apis      []exportedApi // 按 Key 排序
+	byKey     map[string]int
+}
+
+// exportedApi 导出文档中的一个接口
+type exportedApi struct {
+	Key         string
+	Folder      string
+	ContentHash string
+	Detail      ApiDetail
+}
+
+// exportReuseKey context 中保存同一次同步共享导出结果的键
+type exportReuseKey struct{}
+
+// exportReuse 同一次同步中由树形列表或映射导出的结果
+type exportReuse struct {
+	mutex    sync.Mutex
+	snapshot *exportSnapshot
+}
+
+// WithExportReuse 返回同一次同步使用的 ctx：用它获取树形列表或映射后，获取详情时直接使用这次导出的结果，
+// 不受 exportCacheTTL 限制；网页端接口忽略该设置
+func WithExportReuse(ctx context.Context) context.Context {
+	return context.WithValue(ctx, exportReuseKey{}, &exportReuse{})
+}
+
+// NewOpenAPIClient 创建 Open API 客户端
+func NewOpenAPIClient(cfg *config.ApifoxConfig, logger *logrus.Logger) *OpenAPIClient {
+	tracker := newAuthTracker(cfg.Authorization, logger)
+	return &OpenAPIClient{
+		authTracker: tracker,
+		config:      cfg,
+		httpClient:  newHTTPClient(cfg, tracker, logger),
+		logger:      logger,
+	}
+}
+
+// GetConfig 返回客户端配置
+func (c *OpenAPIClient) GetConfig() *config.ApifoxConfig {
+	return c.config
+}
+
+// GetApiTreeList 导出项目并转换为与网页端一致的树形列表，接口平铺在第一层
+func (c *OpenAPIClient) GetApiTreeList(ctx context.Context) (*ApiTreeListResponse, error) {
+	snapshot, err := c.refreshSnapshot(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	shareSnapshot(ctx, snapshot)
+
+	items := make([]*ApiTreeItem, 0, len(snapshot.apis))
+	for _, api := range snapshot.apis {
+		items = append(items, &ApiTreeItem{
+			Key:  api.Key,
+			Type: TreeTypeApi,
+			Name: api.Detail.Name,
+			Api: &ApiBasic{
+				ID:            api.Detail.ID,
+				Name:          api.Detail.Name,
+				Type:          api.Detail.Type,
+				Method:        api.Detail.Method,
+				Path:          api.Detail.Path,
+				Tags:          api.Detail.Tags,
+				Status:        api.Detail.Status,
+				ResponsibleID: api.Detail.ResponsibleID,
+				// 导出结果没有更新时间，以内容摘要代替，供增量同步判断是否变化
+				CustomApiFields: map[string]string{"folder": api.Folder, "contentHash": api.ContentHash},
+			},
+		})
+	}
+
+	return &ApiTreeListResponse{Success: true, Data: *NewApiTree(items)}, nil
+}
+
+// GetApiMappings 导出项目并返回以 "method path" 为键的 API 基本信息
+func (c *OpenAPIClient) GetApiMappings(ctx context.Context) (map[string]ApiBasic, error) {
+	snapshot, err := c.refreshSnapshot(ctx, time.Now())
+	if err != nil {
+		c.logger.WithError(err).Error("获取API映射时无法导出项目")
+		return nil, err
+	}
+	shareSnapshot(ctx, snapshot)
+
+	mappings := make(map[string]ApiBasic, len(snapshot.apis))
+	for _, api := range snapshot.apis {
+		mappings[strings.ToLower(api.Detail.Method)+" "+api.Detail.Path] = ApiBasic{
+			ID:            api.Detail.ID,
+			Name:          api.Detail.Name,
+			Method:        api.Detail.Method,
+			Path:          api.Detail.Path,
+			ResponsibleID: api.Detail.ResponsibleID,
+		}
+	}
+
+	c.logger.WithField("mapping_count", len(mappings)).Info("成功获取API映射信息")
+	return mappings, nil
+}
+
+// GetApiDetail 从导出结果中获取单个 API 的详情：同一次同步中优先使用该次导出的结果，
+// 否则上次导出超过 exportCacheTTL、或找不到该 API 且上次导出超过 exportMissInterval 时重新导出
+func (c *OpenAPIClient) GetApiDetail(ctx context.Context, apiKey string) (*ApiDetailResponse, error) {
+	if snapshot := sharedSnapshot(ctx); snapshot != nil {
+		if index, ok := snapshot.byKey[apiKey]; ok {
+			return &ApiDetailResponse{Success: true, Data: snapshot.apis[index].Detail}, nil
+		}
+	}
+
+	snapshot, err := c.cachedSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index, ok := snapshot.byKey[apiKey]
+	if !ok && time.Since(snapshot.fetchedAt) >= exportMissInterval {
+		if snapshot, err = c.refreshSnapshot(ctx, time.Now().Add(-exportMissInterval)); err != nil {
+			return nil, err
+		}
+		index, ok = snapshot.byKey[apiKey]
+	}
+	if !ok {
+		return nil, fmt.Errorf("导出结果中不存在 API: %s", apiKey)
+	}
+
+	return &ApiDetailResponse{Success: true, Data: snapshot.apis[index].Detail}, nil
+}
+
+// VerifyToken 用候选令牌导出一次项目，不影响当前令牌及其状态；令牌被拒绝时返回 ErrUnauthorized
+func (c *OpenAPIClient) VerifyToken(ctx context.Context, token string) error {
+	resp, err := c.export(context.WithValue(ctx, verifyTokenKey{}, true), token)
+	if err != nil {
+		return fmt.Errorf("验证 Apifox 凭证失败: %w", err)
+	}
+	if isAuthFailure(resp.StatusCode()) {
+		return fmt.Errorf("验证 Apifox 凭证失败: %w (HTTP %d)", ErrUnauthorized, resp.StatusCode())
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("验证 Apifox 凭证失败: HTTP %d", resp.StatusCode())
+	}
+	return nil
+}
+
+// shareSnapshot 在同一次同步的 ctx 中记录导出结果，ctx 不是 WithExportReuse 返回的时不做处理
+func shareSnapshot(ctx context.Context, snapshot *exportSnapshot) {
+	if reuse, ok := ctx.Value(exportReuseKey{}).(*exportReuse); ok {
+		reuse.mutex.Lock()
+		reuse.snapshot = snapshot
+		reuse.mutex.Unlock()
+	}
+}
+
+// sharedSnapshot 返回同一次同步中记录的导出结果，没有时返回 nil
+func sharedSnapshot(ctx context.Context) *exportSnapshot {
+	reuse, ok := ctx.Value(exportReuseKey{}).(*exportReuse)
+	if !ok {
+		return nil
+	}
+	reuse.mutex.Lock()
+	defer reuse.mutex.Unlock()
+	return reuse.snapshot
+}
+
+// currentSnapshot 返回最近一次导出的结果，没有时返回 nil
+func (c *OpenAPIClient) currentSnapshot() *exportSnapshot {
+	c.snapshotMutex.Lock()
+	defer c.snapshotMutex.Unlock()
+	return c.snapshot
+}
+
+// cachedSnapshot 返回未过期的导出结果，过期时重新导出
+func (c *OpenAPIClient) cachedSnapshot(ctx context.Context) (*exportSnapshot, error) {
+	now := time.Now()
+	if snapshot := c.currentSnapshot(); snapshot != nil && now.Sub(snapshot.fetchedAt) < exportCacheTTL {
+		return snapshot, nil
+	}
+	return c.refreshSnapshot(ctx, now.Add(-exportCacheTTL))
+}
+
+// refreshSnapshot 返回 after 之后开始的导出结果；同一时间只进行一次导出，
+// 等待期间其他调用完成的导出满足条件时直接复用，避免并发获取详情时重复导出整个项目
+func (c *OpenAPIClient) refreshSnapshot(ctx context.Context, after time.Time) (*exportSnapshot, error) {
+	c.fetchMutex.Lock()
+	defer c.fetchMutex.Unlock()
+
+	if snapshot := c.currentSnapshot(); snapshot != nil && !snapshot.fetchedAt.Before(after) {
+		return snapshot, nil
+	}
+	return c.fetchSnapshot(ctx)
+}
+
+// fetchSnapshot 导出项目并转换，结果缓存供后续获取详情使用；调用方需持有 fetchMutex
+func (c *OpenAPIClient) fetchSnapshot(ctx context.Context) (*exportSnapshot, error) {
+	c.logger.WithField("project_id", c.config.ProjectID).Info("正在通过 Open API 导出项目")
+	startedAt := time.Now()
+
+	resp, err := c.export(ctx, c.currentAuthorization())
+	if err != nil {
+		c.logger.WithError(err).Error("导出项目失败")
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		c.logger.WithFields(logrus.Fields{
+			"status_code": resp.StatusCode(),
+			"response":    string(resp.Body()),
+		}).Error("导出项目返回非成功状态码")
+		if isAuthFailure(resp.StatusCode()) {
+			return nil, fmt.Errorf("导出项目失败: %w (HTTP %d)", ErrUnauthorized, resp.StatusCode())
+		}
+		return nil, fmt.Errorf("导出项目失败: HTTP %d", resp.StatusCode())
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(resp.Body(), &doc); err != nil {
+		return nil, fmt.Errorf("解析导出的 OpenAPI 文档失败: %w", err)
+	}
+
+	snapshot := &exportSnapshot{
+		fetchedAt: startedAt,
+		apis:      convertOpenAPIDocument(doc),
+	}
+	snapshot.byKey = make(map[string]int, len(snapshot.apis))
+	for i, api := range snapshot.apis {
+		snapshot.byKey[api.Key] = i
+	}
+
+	c.snapshotMutex.Lock()
+	c.snapshot = snapshot
+	c.snapshotMutex.Unlock()
+
+	c.logger.WithField("api_count", len(snapshot.apis)).Info("项目导出完成")
+	return snapshot, nil
+}
+
+// export 调用导出接口，导出不修改数据，失败时与 GET 请求一样重试
+func (c *OpenAPIClient) export(ctx context.Context, token string) (*resty.Response, error) {
+	url := fmt.Sprintf("%s/v1/projects/%s/export-openapi?locale=zh-CN",
+		strings.TrimRight(c.config.OpenAPIBaseURL, "/"), c.config.ProjectID)
+
+	body := map[string]interface{}{
+		"scope": map[string]interface{}{"type": "ALL"},
+		"options": map[string]interface{}{
+			"includeApifoxExtensionProperties": true,
+			"addFoldersToTags":                 false,
+		},
+		"oasVersion":   "3.1",
+		"exportFormat": "JSON",
+	}
+	if branchID, err := strconv.Atoi(c.config.BranchID); err == nil {
+		body["branchId"] = branchID
+	}
+
+	return c.httpClient.R().
+		SetContext(context.WithValue(ctx, idempotentKey{}, true)).
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
+		SetHeader("X-Apifox-Api-Version", c.config.OpenAPIVersion).
+		SetHeader("Content-Type", "application/json").
+		SetBody(body).
+		Post(url)
+}
+
+// convertOpenAPIDocument 将导出的 OpenAPI 文档转换为接口列表，按 Key 排序
+func convertOpenAPIDocument(doc map[string]interface{}) []exportedApi {
+	resolver := &refResolver{doc: doc}
+	paths, _ := doc["paths"].(map[string]interface{})
+
+	var apis []exportedApi
+	for path, rawItem := range paths {
+		pathItem, ok := resolver.resolve(rawItem).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		shared, _ := pathItem["parameters"].([]interface{})
+		for _, method := range operationMethods {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			apis = append(apis, convertOperation(method, path, operation, shared))
+		}
+	}
+
+	sort.Slice(apis, func(i, j int) bool {
+		return apis[i].Key < apis[j].Key
+	})
+	return apis
+}
+
+// convertOperation 将一个 OpenAPI 操作转换为 ApiDetail，$ref 已展开
+func convertOperation(method, path string, operation map[string]interface{}, shared []interface{}) exportedApi {
+	detail := ApiDetail{
+		ID:            operationID(method, path, operation),
+		Type:          "http",
+		Method:        method,
+		Path:          path,
+		Name:          stringValue(operation["summary"]),
+		Description:   stringValue(operation["description"]),
+		Status:        stringValue(operation["x-apifox-status"]),
+		OperationID:   stringValue(operation["operationId"]),
+		ResponsibleID: intValue(operation["x-apifox-maintainer"]),
+	}
+	if detail.Name == "" {
+		detail.Name = strings.ToUpper(method) + " " + path
+	}
+	for _, tag := range sliceValue(operation["tags"]) {
+		if s, ok := tag.(string); ok {
+			detail.Tags = append(detail.Tags, s)
+		}
+	}
+
+	// 操作上的参数覆盖路径项上同名同位置的参数
+	operationParams := sliceValue(operation["parameters"])
+	params := make([]interface{}, 0, len(shared)+len(operationParams))
+	overridden := make(map[string]bool)
+	for _, p := range operationParams {
+		if m, ok := p.(map[string]interface{}); ok {
+			overridden[stringValue(m["in"])+":"+stringValue(m["name"])] = true
+		}
+	}
+	for _, p := range shared {
+		if m, ok := p.(map[string]interface{}); ok && !overridden[stringValue(m["in"])+":"+stringValue(m["name"])] {
+			params = append(params, p)
+		}
+	}
+	params = append(params, operationParams...)
+
+	for _, p := range params {
+		m, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		param := Parameter{
+			Name:        stringValue(m["name"]),
+			Required:    boolValue(m["required"]),
+			Description: stringValue(m["description"]),
+			Enable:      true,
+		}
+		if schema, ok := m["schema"].(map[string]interface{}); ok {
+			param.Type = stringValue(schema["type"])
+		}
+		switch stringValue(m["in"]) {
+		case "query":
+			detail.Parameters.Query = append(detail.Parameters.Query, param)
+		case "path":
+			detail.Parameters.Path = append(detail.Parameters.Path, param)
+		case "header":
+			detail.Parameters.Header = append(detail.Parameters.Header, param)
+		case "cookie":
+			detail.Parameters.Cookie = append(detail.Parameters.Cookie, param)
+		}
+	}
+
+	if requestBody, ok := operation["requestBody"].(map[string]interface{}); ok {
+		detail.RequestBody = convertRequestBody(requestBody)
+	} else {
+		detail.RequestBody = RequestBody{Type: "none"}
+	}
+
+	if responses, ok := operation["responses"].(map[string]interface{}); ok {
+		codes := make([]string, 0, len(responses))
+		for code := range responses {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			if response, ok := responses[code].(map[string]interface{}); ok {
+				resp, examples := convertResponse(code, response)
+				detail.Responses = append(detail.Responses, resp)
+				detail.ResponseExamples = append(detail.ResponseExamples, examples...)
+			}
+		}
+	}
+
+	// 操作级的 security 覆盖文档级设置，原样保存
+	if security, ok := operation["security"]; ok {
+		detail.Auth = security
+	}
+
+	// 以转换后的详情计算摘要，参与比较的内容变化时摘要随之变化
+	hash := ""
+	if data, err := json.Marshal(detail); err == nil {
+		sum := sha256.Sum256(data)
+		hash = hex.EncodeToString(sum[:16])
+	}
+
+	// 保留展开引用后的原始操作定义
+	if raw, err := json.Marshal(operation); err == nil {
+		detail.Raw = raw
+	}
+
+	return exportedApi{
+		Key:         fmt.Sprintf("apiDetail.%d", detail.ID),
+		Folder:      stringValue(operation["x-apifox-folder"]),
+		ContentHash: hash,
+		Detail:      detail,
+	}
+}
+
+// convertRequestBody 转换请求体，表单类请求体的字段转换为参数，其余保留 JSON Schema
+func convertRequestBody(requestBody map[string]interface{}) RequestBody {
+	content, _ := requestBody["content"].(map[string]interface{})
+	mediaType := preferredMediaType(content)
+	if mediaType == "" {
+		return RequestBody{Type: "none"}
+	}
+
+	rb := RequestBody{Type: mediaType, MediaType: mediaType}
+	media, _ := content[mediaType].(map[string]interface{})
+	schema, _ := media["schema"].(map[string]interface{})
+
+	if mediaType == "multipart/form-data" || mediaType == "application/x-www-form-urlencoded" {
+		required := make(map[string]bool)
+		for _, name := range sliceValue(schema["required"]) {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, _ := properties[name].(map[string]interface{})
+			rb.Parameters = append(rb.Parameters, Parameter{
+				Name:        name,
+				Required:    required[name],
+				Description: stringValue(property["description"]),
+				Type:        stringValue(property["type"]),
+				Enable:      true,
+			})
+		}
+	} else if schema != nil {
+		rb.JsonSchema = schema
+	}
+
+	rb.Examples = convertExamples(mediaType, media)
+	return rb
+}
+
+// convertExamples 转换媒体类型下的 example 和 examples，examples 按名称排序
+func convertExamples(mediaType string, media map[string]interface{}) []Example {
+	var result []Example
+	if example, ok := media["example"]; ok {
+		result = append(result, Example{MediaType: mediaType, Value: example})
+	}
+	if examples, ok := media["examples"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(examples))
+		for name := range examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			example, _ := examples[name].(map[string]interface{})
+			result = append(result, Example{
+				Name:        name,
+				MediaType:   mediaType,
+				Description: stringValue(example["summary"]),
+				Value:       example["value"],
+			})
+		}
+	}
+	return result
+}
+
+// convertResponse 转换一个状态码的响应及其示例，default 等非数字状态码记为 0；
+// 导出结果没有响应 ID，以状态码代替，示例通过它关联到响应
+func convertResponse(code string, response map[string]interface{}) (Response, []ResponseExample) {
+	statusCode, _ := strconv.Atoi(code)
+	resp := Response{
+		ID:          statusCode,
+		Code:        statusCode,
+		Name:        stringValue(response["x-apifox-name"]),
+		Description: stringValue(response["description"]),
+		ContentType: "none",
+	}
+	if resp.Name == "" {
+		resp.Name = resp.Description
+	}
+
+	var examples []ResponseExample
+	content, _ := response["content"].(map[string]interface{})
+	if mediaType := preferredMediaType(content); mediaType != "" {
+		resp.ContentType = mediaType
+		if strings.Contains(mediaType, "json") {
+			resp.ContentType = "json"
+		}
+		if media, ok := content[mediaType].(map[string]interface{}); ok {
+			resp.JsonSchema = media["schema"]
+			for _, example := range convertExamples(mediaType, media) {
+				data, _ := json.Marshal(example.Value)
+				name := example.Name
+				if name == "" {
+					name = resp.Name
+				}
+				examples = append(examples, ResponseExample{ResponseID: resp.ID, Name: name, Data: string(data)})
+			}
+		}
+	}
+
+	// 响应头按名称排序
+	if headers, ok := response["headers"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(headers))
+		for name := range headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			header, _ := headers[name].(map[string]interface{})
+			param := Parameter{
+				Name:        name,
+				Required:    boolValue(header["required"]),
+				Description: stringValue(header["description"]),
+				Enable:      true,
+			}
+			if schema, ok := header["schema"].(map[string]interface{}); ok {
+				param.Type = stringValue(schema["type"])
+			}
+			resp.Headers = append(resp.Headers, param)
+		}
+	}
+	return resp, examples
+}
+
+// preferredMediaType 选择内容类型，优先 JSON，其次按字母序第一个
+func preferredMediaType(content map[string]interface{}) string {
+	types := make([]string, 0, len(content))
+	for mediaType := range content {
+		types = append(types, mediaType)
+	}
+	sort.Strings(types)
+	for _, mediaType := range types {
+		if strings.Contains(mediaType, "json") {
+			return mediaType
+		}
+	}
+	if len(types) > 0 {
+		return types[0]
+	}
+	return ""
+}
+
+// operationID 从 x-run-in-apifox 链接中提取接口 ID；
+// 没有该扩展字段时按方法和路径生成稳定的 ID，此时路径变更会表现为删除后新增
+func operationID(method, path string, operation map[string]interface{}) int {
+	if match := runInApifoxPattern.FindStringSubmatch(stringValue(operation["x-run-in-apifox"])); match != nil {
+		if id, err := strconv.Atoi(match[1]); err == nil {
+			return id
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(method) + " " + path))
+	// 取前 4 字节并清除最高位，保证为正数
+	return int(uint32(sum[0]&0x7f)<<24 | uint32(sum[1])<<16 | uint32(sum[2])<<8 | uint32(sum[3]))
+}
+
+// refResolver 展开文档内的 $ref 引用，循环引用保留 $ref
+type refResolver struct {
+	doc map[string]interface{}
+}
+
+// resolve 返回展开引用后的副本
+func (r *refResolver) resolve(value interface{}) interface{} {
+	return r.resolveWith(value, make(map[string]bool))
+}
+
+func (r *refResolver) resolveWith(value interface{}, visiting map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, "#/") {
+			target, found := r.lookup(ref)
+			if visiting[ref] || !found {
+				return map[string]interface{}{"$ref": ref}
+			}
+			visiting[ref] = true
+			resolved := r.resolveWith(target, visiting)
+			delete(visiting, ref)
+			return resolved
+		}
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = r.resolveWith(item, visiting)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = r.resolveWith(item, visiting)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// lookup 按 JSON Pointer 查找引用目标
+func (r *refResolver) lookup(ref string) (interface{}, bool) {
+	var current interface{} = r.doc
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// stringValue 取字符串值，类型不符时返回空字符串
+func stringValue(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+// boolValue 取布尔值，类型不符时返回 false
+func boolValue(value interface{}) bool {
+	b, _ := value.(bool)
+	return b
+}
+
+// intValue 取整数值，支持数字和数字字符串，无法转换时返回 0
+func intValue(value interface{}) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// sliceValue 取数组值，类型不符时返回 nil
+func sliceValue(value interface{}) []interface{} {
+	s, _ := value.([]interface{})
+	return s
+}
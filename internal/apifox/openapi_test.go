@@ -0,0 +1,283 @@
+package apifox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/config"
+)
+
+// exportFixture 导出的 OpenAPI 文档示例，包含嵌套和循环的 $ref、路径项与操作上的参数以及多个响应
+const exportFixture = "testdata/openapi/export.json"
+
+// updateUserID 没有 x-run-in-apifox 时按 "put /users/{id}" 生成的 ID，改变会导致已存储的接口全部变为删除后新增
+const updateUserID = 1208706224
+
+// updateUserKey updateUserID 对应的 Key
+const updateUserKey = "apiDetail.1208706224"
+
+// loadExport 读取并解码导出示例，每次返回新的副本
+func loadExport(t *testing.T) map[string]interface{} {
+	t.Helper()
+
+	data, err := os.ReadFile(exportFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// newExportServer 以导出示例模拟 Open API 的导出接口，返回指向它的客户端和已收到的导出请求数
+func newExportServer(t *testing.T) (*OpenAPIClient, *int32) {
+	t.Helper()
+
+	var exports int32
+
+	data, err := os.ReadFile(exportFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/projects/1/export-openapi" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&exports, 1)
+		// 导出整个项目较慢，留出让并发请求重叠的时间
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewOpenAPIClient(&config.ApifoxConfig{
+		Mode:           config.ApifoxModeOpenAPI,
+		ProjectID:      "1",
+		Authorization:  "token",
+		OpenAPIBaseURL: server.URL,
+	}, logger), &exports
+}
+
+// TestOpenAPITreeAndMappings 导出结果平铺为树形列表，方法和路径映射到 x-run-in-apifox 中的 ID 或生成的 ID
+func TestOpenAPITreeAndMappings(t *testing.T) {
+	client, _ := newExportServer(t)
+	ctx := context.Background()
+
+	tree, err := client.GetApiTreeList(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, item := range tree.Data.Apis() {
+		keys = append(keys, item.Key)
+	}
+	if want := []string{"apiDetail.101", "apiDetail.102", updateUserKey}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("树形列表 = %v, want %v", keys, want)
+	}
+
+	item, _ := tree.Data.FindByKey("apiDetail.101")
+	fields, _ := item.Api.CustomApiFields.(map[string]string)
+	if item.Api.ResponsibleID != 7 || item.Api.Status != StatusReleased || fields["folder"] != "用户" {
+		t.Errorf("apiDetail.101 = %+v", item.Api)
+	}
+	if fields["contentHash"] == "" {
+		t.Error("缺少内容摘要")
+	}
+
+	mappings, err := client.GetApiMappings(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]int, len(mappings))
+	for key, api := range mappings {
+		ids[key] = api.ID
+	}
+	want := map[string]int{
+		"get /users/{id}":  101,
+		"put /users/{id}":  updateUserID,
+		"get /departments": 102,
+	}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("映射 = %v, want %v", ids, want)
+	}
+}
+
+// TestOpenAPIDetail 参数按位置归类且操作覆盖路径项，$ref 展开，循环引用保留 $ref，响应按状态码转换
+func TestOpenAPIDetail(t *testing.T) {
+	client, _ := newExportServer(t)
+
+	resp, err := client.GetApiDetail(context.Background(), "apiDetail.101")
+	if err != nil {
+		t.Fatal(err)
+	}
+	detail := resp.Data
+
+	if want := []Parameter{{Name: "id", Required: true, Description: "用户ID", Type: "integer", Enable: true}}; !reflect.DeepEqual(detail.Parameters.Path, want) {
+		t.Errorf("路径参数 = %+v", detail.Parameters.Path)
+	}
+	if want := []Parameter{{Name: "fields", Description: "返回的字段", Type: "string", Enable: true}}; !reflect.DeepEqual(detail.Parameters.Query, want) {
+		t.Errorf("查询参数 = %+v", detail.Parameters.Query)
+	}
+	if want := []Parameter{{Name: "X-Tenant", Required: true, Description: "租户", Type: "string", Enable: true}}; !reflect.DeepEqual(detail.Parameters.Header, want) {
+		t.Errorf("请求头参数 = %+v", detail.Parameters.Header)
+	}
+	if detail.RequestBody.Type != "none" {
+		t.Errorf("请求体 = %+v", detail.RequestBody)
+	}
+
+	if len(detail.Responses) != 2 {
+		t.Fatalf("响应 = %+v", detail.Responses)
+	}
+	ok, notFound := detail.Responses[0], detail.Responses[1]
+	if ok.Code != 200 || ok.ID != 200 || ok.Name != "成功" || ok.ContentType != "json" {
+		t.Errorf("200 响应 = %+v", ok)
+	}
+	if want := []Parameter{{Name: "X-Rate-Limit", Description: "剩余次数", Type: "integer", Enable: true}}; !reflect.DeepEqual(ok.Headers, want) {
+		t.Errorf("响应头 = %+v", ok.Headers)
+	}
+	if notFound.Code != 404 || notFound.Name != "未找到" {
+		t.Errorf("404 响应 = %+v", notFound)
+	}
+	if want := map[string]interface{}{"type": "string"}; !reflect.DeepEqual(schemaAt(t, notFound.JsonSchema, "properties", "message"), want) {
+		t.Errorf("引用的响应未展开: %v", notFound.JsonSchema)
+	}
+
+	// User -> Department -> children.items -> Department，第二次进入 Department 时保留 $ref
+	department := schemaAt(t, ok.JsonSchema, "properties", "department")
+	if want := map[string]interface{}{"type": "string"}; !reflect.DeepEqual(schemaAt(t, department, "properties", "name"), want) {
+		t.Errorf("嵌套引用未展开: %v", department)
+	}
+	if want := map[string]interface{}{"$ref": "#/components/schemas/Department"}; !reflect.DeepEqual(schemaAt(t, department, "properties", "children", "items"), want) {
+		t.Errorf("循环引用应保留 $ref: %v", department)
+	}
+
+	if want := []ResponseExample{{ResponseID: 200, Name: "成功", Data: `{"id":1,"name":"张三"}`}}; !reflect.DeepEqual(detail.ResponseExamples, want) {
+		t.Errorf("响应示例 = %+v", detail.ResponseExamples)
+	}
+	if detail.Auth == nil {
+		t.Error("缺少鉴权设置")
+	}
+
+	resp, err = client.GetApiDetail(context.Background(), updateUserKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := resp.Data.RequestBody; body.Type != "application/json" || schemaAt(t, body.JsonSchema, "properties", "id") == nil {
+		t.Errorf("请求体 = %+v", body)
+	}
+}
+
+// TestOpenAPISnapshotReuse 并发获取详情只导出一次，找不到的接口不会反复导出，同步中复用树形列表的导出结果
+func TestOpenAPISnapshotReuse(t *testing.T) {
+	client, exports := newExportServer(t)
+
+	getDetails := func(ctx context.Context, apiKey string) int {
+		var wg sync.WaitGroup
+		var failures int32
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := client.GetApiDetail(ctx, apiKey); err != nil {
+					atomic.AddInt32(&failures, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		return int(failures)
+	}
+
+	if failures := getDetails(context.Background(), "apiDetail.101"); failures != 0 {
+		t.Fatalf("%d 次获取详情失败", failures)
+	}
+	if got := atomic.LoadInt32(exports); got != 1 {
+		t.Fatalf("并发获取详情导出 %d 次, want 1", got)
+	}
+
+	if failures := getDetails(context.Background(), "apiDetail.999"); failures != 10 {
+		t.Fatalf("获取不存在的接口失败 %d 次, want 10", failures)
+	}
+	if got := atomic.LoadInt32(exports); got != 1 {
+		t.Fatalf("查找不存在的接口导出 %d 次, want 1", got)
+	}
+
+	// 同步耗时超过 exportCacheTTL 时，详情仍取自本次同步获取树形列表时的导出
+	ctx := WithExportReuse(context.Background())
+	if _, err := client.GetApiTreeList(ctx); err != nil {
+		t.Fatal(err)
+	}
+	client.currentSnapshot().fetchedAt = time.Now().Add(-2 * exportCacheTTL)
+	if failures := getDetails(ctx, "apiDetail.102"); failures != 0 {
+		t.Fatalf("%d 次获取详情失败", failures)
+	}
+	if got := atomic.LoadInt32(exports); got != 2 {
+		t.Fatalf("同步中获取详情后共导出 %d 次, want 2", got)
+	}
+
+	// 不在同步中时，过期的结果重新导出一次
+	if failures := getDetails(context.Background(), "apiDetail.102"); failures != 0 {
+		t.Fatalf("%d 次获取详情失败", failures)
+	}
+	if got := atomic.LoadInt32(exports); got != 3 {
+		t.Fatalf("结果过期后共导出 %d 次, want 3", got)
+	}
+}
+
+// TestOpenAPIStableConversion 同一文档两次转换得到相同的 ID 和内容摘要，内容变化时只有受影响的接口摘要改变
+func TestOpenAPIStableConversion(t *testing.T) {
+	first := convertOpenAPIDocument(loadExport(t))
+	second := convertOpenAPIDocument(loadExport(t))
+	if len(first) != 3 || len(second) != len(first) {
+		t.Fatalf("接口数量 = %d, %d", len(first), len(second))
+	}
+	for i := range first {
+		if first[i].Key != second[i].Key || first[i].ContentHash != second[i].ContentHash {
+			t.Errorf("两次转换不一致: %s %s / %s %s", first[i].Key, first[i].ContentHash, second[i].Key, second[i].ContentHash)
+		}
+	}
+
+	// 修改只被 /users/{id} 引用的 User 结构
+	doc := loadExport(t)
+	user := schemaAt(t, doc, "components", "schemas", "User").(map[string]interface{})
+	user["required"] = []interface{}{"id", "name"}
+	changed := convertOpenAPIDocument(doc)
+
+	hashes := make(map[string]bool)
+	for i := range first {
+		hashes[changed[i].Key] = changed[i].ContentHash != first[i].ContentHash
+	}
+	want := map[string]bool{"apiDetail.101": true, updateUserKey: true, "apiDetail.102": false}
+	if !reflect.DeepEqual(hashes, want) {
+		t.Errorf("摘要是否变化 = %v, want %v", hashes, want)
+	}
+}
+
+// schemaAt 按键依次取出嵌套的 map 值，中途不是 map 时测试失败
+func schemaAt(t *testing.T, value interface{}, keys ...string) interface{} {
+	t.Helper()
+
+	for _, key := range keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			t.Fatalf("%v 不是对象，无法取 %s", value, key)
+		}
+		value = m[key]
+	}
+	return value
+}
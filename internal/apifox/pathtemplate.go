@@ -0,0 +1,136 @@
+package apifox
+
+import (
+	"strings"
+)
+
+// PathTemplate 解析后的路径模板，如 /users/{id}/orders
+type PathTemplate struct {
+	Segments []PathSegment
+}
+
+// PathSegment 路径模板中的一段，Param 非空时为路径参数，否则为固定文本 Literal；
+// 只有整段为 {name} 时才视为路径参数
+type PathSegment struct {
+	Literal string
+	Param   string
+}
+
+// IsParam 是否为路径参数
+func (s PathSegment) IsParam() bool {
+	return s.Param != ""
+}
+
+// ParsePathTemplate 解析路径模板，忽略查询字符串、首尾的 "/" 和空段
+func ParsePathTemplate(path string) PathTemplate {
+	var template PathTemplate
+	for _, part := range splitPath(path) {
+		if len(part) > 2 && strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			template.Segments = append(template.Segments, PathSegment{Param: part[1 : len(part)-1]})
+		} else {
+			template.Segments = append(template.Segments, PathSegment{Literal: part})
+		}
+	}
+	return template
+}
+
+// splitPath 去掉查询字符串后按 "/" 拆分路径
+func splitPath(path string) []string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	var parts []string
+	for _, part := range strings.Split(path, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// String 还原为路径字符串
+func (t PathTemplate) String() string {
+	parts := make([]string, len(t.Segments))
+	for i, s := range t.Segments {
+		if s.IsParam() {
+			parts[i] = "{" + s.Param + "}"
+		} else {
+			parts[i] = s.Literal
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// Shape 参数名统一替换为 {} 后的路径，只重命名路径参数时前后相同
+func (t PathTemplate) Shape() string {
+	parts := make([]string, len(t.Segments))
+	for i, s := range t.Segments {
+		if s.IsParam() {
+			parts[i] = "{}"
+		} else {
+			parts[i] = s.Literal
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// Params 按出现顺序返回路径参数名
+func (t PathTemplate) Params() []string {
+	var params []string
+	for _, s := range t.Segments {
+		if s.IsParam() {
+			params = append(params, s.Param)
+		}
+	}
+	return params
+}
+
+// Match 将具体路径（如 /users/42）与模板匹配，成功时返回路径参数的取值
+func (t PathTemplate) Match(path string) (map[string]string, bool) {
+	parts := splitPath(path)
+	if len(parts) != len(t.Segments) {
+		return nil, false
+	}
+	values := make(map[string]string)
+	for i, s := range t.Segments {
+		if s.IsParam() {
+			values[s.Param] = parts[i]
+		} else if s.Literal != parts[i] {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// MoreSpecific 两个模板都能匹配同一路径时，t 是否应当优先：
+// 从左到右第一处不同的段上，固定文本优先于路径参数，与常见路由器的规则一致
+func (t PathTemplate) MoreSpecific(other PathTemplate) bool {
+	for i := 0; i < len(t.Segments) && i < len(other.Segments); i++ {
+		a, b := t.Segments[i], other.Segments[i]
+		if a.IsParam() != b.IsParam() {
+			return !a.IsParam()
+		}
+	}
+	return false
+}
+
+// PathParamRename 路径参数重命名
+type PathParamRename struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// PathParamRenames 两个路径只有路径参数名不同时返回重命名列表，ok 为 true；
+// 路径结构（固定文本或段数）不同时 ok 为 false
+func PathParamRenames(oldPath, newPath string) (renames []PathParamRename, ok bool) {
+	oldTemplate, newTemplate := ParsePathTemplate(oldPath), ParsePathTemplate(newPath)
+	if oldTemplate.Shape() != newTemplate.Shape() {
+		return nil, false
+	}
+	for i, s := range oldTemplate.Segments {
+		if s.IsParam() && s.Param != newTemplate.Segments[i].Param {
+			renames = append(renames, PathParamRename{Old: s.Param, New: newTemplate.Segments[i].Param})
+		}
+	}
+	return renames, true
+}
@@ -0,0 +1,58 @@
+package apifox
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestPathTemplateMatch 具体路径与模板匹配，忽略查询字符串和末尾的 "/"
+func TestPathTemplateMatch(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		want     map[string]string
+		ok       bool
+	}{
+		{"/users/{id}", "/users/42", map[string]string{"id": "42"}, true},
+		{"/users/{id}", "/users/42/?fields=name", map[string]string{"id": "42"}, true},
+		{"/users/{id}/orders/{orderId}", "/users/42/orders/7", map[string]string{"id": "42", "orderId": "7"}, true},
+		{"/users/{id}", "/users", nil, false},
+		{"/users/{id}", "/members/42", nil, false},
+		{"/users/me", "/users/me", map[string]string{}, true},
+		{"/files/{name}.json", "/files/a.json", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParsePathTemplate(tt.template).Match(tt.path)
+		if ok != tt.ok || (ok && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("%s.Match(%s) = %v, %v, want %v, %v", tt.template, tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestPathTemplateMoreSpecific 第一处不同的段上固定文本优先
+func TestPathTemplateMoreSpecific(t *testing.T) {
+	me, byID := ParsePathTemplate("/users/me"), ParsePathTemplate("/users/{id}")
+	if !me.MoreSpecific(byID) || byID.MoreSpecific(me) {
+		t.Error("/users/me 应优先于 /users/{id}")
+	}
+	a, b := ParsePathTemplate("/{org}/users/{id}"), ParsePathTemplate("/{org}/{kind}/1")
+	if !a.MoreSpecific(b) || b.MoreSpecific(a) {
+		t.Error("/{org}/users/{id} 应优先于 /{org}/{kind}/1")
+	}
+}
+
+// TestPathParamRenames 只有参数名不同的路径返回重命名列表
+func TestPathParamRenames(t *testing.T) {
+	renames, ok := PathParamRenames("/users/{id}/orders/{oid}", "/users/{userId}/orders/{oid}")
+	if want := []PathParamRename{{Old: "id", New: "userId"}}; !ok || !reflect.DeepEqual(renames, want) {
+		t.Errorf("renames = %v, %v, want %v", renames, ok, want)
+	}
+
+	if _, ok := PathParamRenames("/users/{id}", "/members/{id}"); ok {
+		t.Error("固定文本不同时不应视为参数重命名")
+	}
+	if _, ok := PathParamRenames("/users/{id}", "/users/{id}/profile"); ok {
+		t.Error("段数不同时不应视为参数重命名")
+	}
+}
@@ -0,0 +1,118 @@
+package apifox
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/config"
+	"github.com/xhy/api-pulse/internal/metrics"
+	"golang.org/x/time/rate"
+)
+
+// newRateLimiter 根据配置创建全局限流器，RateLimit 为 0 时不限流
+func newRateLimiter(cfg *config.ApifoxConfig) *rate.Limiter {
+	if cfg.RateLimit <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	burst := cfg.RateBurst
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(cfg.RateLimit), burst)
+}
+
+// configureResilience 为客户端配置限流与重试：
+// 每次发出请求（包括重试）前都要从共享的限流器取得令牌，
+// 幂等请求遇到网络错误、429 或 5xx 时按带抖动的指数退避重试，响应带 Retry-After 时按其等待
+func configureResilience(client *resty.Client, cfg *config.ApifoxConfig, limiter *rate.Limiter, logger *logrus.Logger) {
+	client.OnBeforeRequest(func(c *resty.Client, req *resty.Request) error {
+		return limiter.Wait(req.Context())
+	})
+
+	if cfg.RetryCount <= 0 {
+		return
+	}
+
+	client.
+		SetRetryCount(cfg.RetryCount).
+		SetRetryWaitTime(cfg.RetryWait).
+		SetRetryMaxWaitTime(cfg.RetryMaxWait).
+		SetRetryAfter(retryAfter).
+		AddRetryCondition(shouldRetry).
+		AddRetryHook(func(resp *resty.Response, err error) {
+			if resp == nil || resp.Request == nil {
+				return
+			}
+			metrics.ApifoxRetries.WithLabelValues(metrics.ApifoxEndpoint(resp.Request.URL)).Inc()
+
+			entry := logger.WithFields(logrus.Fields{
+				"url":     resp.Request.URL,
+				"attempt": resp.Request.Attempt,
+				"status":  resp.StatusCode(),
+			})
+			if err != nil {
+				entry = entry.WithError(err)
+			}
+			entry.Warn("Apifox 请求失败，准备重试")
+		})
+}
+
+// idempotentKey 标记不修改数据的非 GET 请求（如 Open API 导出），失败时可以重试
+type idempotentKey struct{}
+
+// shouldRetry 只重试幂等的请求（GET 或标记了 idempotentKey 的请求），且仅限网络错误、429 和 5xx
+func shouldRetry(resp *resty.Response, err error) bool {
+	if resp == nil || resp.Request == nil {
+		return false
+	}
+	ctx := resp.Request.Context()
+	if resp.Request.Method != http.MethodGet && ctx.Value(idempotentKey{}) == nil {
+		return false
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter 解析 Retry-After 响应头，支持秒数和 HTTP 日期两种格式；
+// 返回 0 时使用默认的抖动退避
+func retryAfter(c *resty.Client, resp *resty.Response) (time.Duration, error) {
+	return parseRetryAfter(resp.Header().Get("Retry-After"), time.Now()), nil
+}
+
+// parseRetryAfter 将 Retry-After 的值转换为等待时长，无法解析时返回 0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
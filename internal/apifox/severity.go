@@ -0,0 +1,24 @@
+package apifox
+
+// ClassifySeverity 根据变更类型和差异中的结构化标记评估严重程度，不依赖详情文本的内容
+func ClassifySeverity(diff *ApiDiff, changeType string) string {
+	switch changeType {
+	case ActionCreated:
+		return SeverityLow
+	case ActionDeleted:
+		return SeverityHigh
+	}
+
+	if diff == nil {
+		return SeverityLow
+	}
+
+	if diff.MethodDiff || diff.PathDiff || diff.AuthDiff || diff.Deprecated || diff.Breaking {
+		return SeverityHigh
+	}
+
+	if diff.HasChanges() {
+		return SeverityMedium
+	}
+	return SeverityLow
+}
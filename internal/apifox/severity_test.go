@@ -0,0 +1,152 @@
+package apifox_test
+
+import (
+	"testing"
+
+	"github.com/xhy/api-pulse/internal/apifox"
+)
+
+// severityApi 用于评估严重程度的 API：一个查询参数、一个请求体字段、两个响应字段和一个响应示例，描述中都带有"删除"
+func severityApi() apifox.ApiDetail {
+	return apifox.ApiDetail{
+		ID:     1,
+		Name:   "删除用户",
+		Method: "post",
+		Path:   "/users/remove",
+		RequestBody: apifox.RequestBody{
+			Type: "application/json",
+			JsonSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"reason": map[string]interface{}{"type": "string", "description": "删除原因"},
+				},
+			},
+		},
+		Parameters: apifox.Parameters{
+			Query: []apifox.Parameter{{Name: "soft", Type: "boolean", Description: "是否软删除", Enable: true}},
+		},
+		Responses:        []apifox.Response{{ID: 1, Code: 200, Name: "成功", JsonSchema: severityResponseSchema("id", "deleted")}},
+		ResponseExamples: []apifox.ResponseExample{{ID: 1, ResponseID: 1, Name: "已删除", Data: `{"ok":true}`}},
+	}
+}
+
+// severityResponseSchema 返回包含指定字段的响应结构，字段 id 为整数，其余为布尔值
+func severityResponseSchema(fields ...string) map[string]interface{} {
+	properties := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		propType := "boolean"
+		if field == "id" {
+			propType = "integer"
+		}
+		properties[field] = map[string]interface{}{"type": propType, "description": "是否已删除"}
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// TestClassifySeverity 严重程度来自结构化的变更，描述、名称等文本中出现"删除"不影响结果
+func TestClassifySeverity(t *testing.T) {
+	cases := []struct {
+		name   string
+		update func(detail *apifox.ApiDetail)
+		want   string
+	}{
+		{
+			name:   "无变更",
+			update: func(detail *apifox.ApiDetail) {},
+			want:   apifox.SeverityLow,
+		},
+		{
+			name: "描述中包含删除",
+			update: func(detail *apifox.ApiDetail) {
+				detail.Parameters.Query[0].Description = "删除后是否可恢复"
+				detail.RequestBody.JsonSchema = map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"reason": map[string]interface{}{"type": "string", "description": "删除的原因，移除后不可恢复"},
+					},
+				}
+			},
+			want: apifox.SeverityMedium,
+		},
+		{
+			name: "删除响应示例",
+			update: func(detail *apifox.ApiDetail) {
+				detail.ResponseExamples = nil
+			},
+			want: apifox.SeverityMedium,
+		},
+		{
+			name: "删除查询参数",
+			update: func(detail *apifox.ApiDetail) {
+				detail.Parameters.Query = nil
+			},
+			want: apifox.SeverityHigh,
+		},
+		{
+			name: "新增必填字段",
+			update: func(detail *apifox.ApiDetail) {
+				detail.RequestBody.JsonSchema = map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"reason":   map[string]interface{}{"type": "string", "description": "删除原因"},
+						"operator": map[string]interface{}{"type": "string"},
+					},
+					"required": []interface{}{"operator"},
+				}
+			},
+			want: apifox.SeverityHigh,
+		},
+		{
+			name: "删除响应字段",
+			update: func(detail *apifox.ApiDetail) {
+				detail.Responses[0].JsonSchema = severityResponseSchema("id")
+			},
+			want: apifox.SeverityHigh,
+		},
+		{
+			name: "响应字段类型变化",
+			update: func(detail *apifox.ApiDetail) {
+				schema := severityResponseSchema("id", "deleted")
+				schema["properties"].(map[string]interface{})["id"] = map[string]interface{}{"type": "string"}
+				detail.Responses[0].JsonSchema = schema
+			},
+			want: apifox.SeverityHigh,
+		},
+		{
+			name: "响应新增必填字段",
+			update: func(detail *apifox.ApiDetail) {
+				schema := severityResponseSchema("id", "deleted", "deletedAt")
+				schema["required"] = []interface{}{"deletedAt"}
+				detail.Responses[0].JsonSchema = schema
+			},
+			want: apifox.SeverityMedium,
+		},
+		{
+			name: "参数类型变化",
+			update: func(detail *apifox.ApiDetail) {
+				detail.Parameters.Query[0].Type = "integer"
+			},
+			want: apifox.SeverityHigh,
+		},
+	}
+
+	service := newDiffService()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			oldApi, newApi := severityApi(), severityApi()
+			c.update(&newApi)
+
+			diff := service.CompareApis(oldApi, newApi, "", "")
+			if got := apifox.ClassifySeverity(diff, apifox.ActionUpdated); got != c.want {
+				t.Errorf("严重程度 = %s, want %s\n%s%s%s", got, c.want, diff.RequestBodyDetail, diff.ParametersDetail, diff.ResponsesDetail)
+			}
+		})
+	}
+
+	if got := apifox.ClassifySeverity(nil, apifox.ActionCreated); got != apifox.SeverityLow {
+		t.Errorf("新增接口 = %s", got)
+	}
+	if got := apifox.ClassifySeverity(nil, apifox.ActionDeleted); got != apifox.SeverityHigh {
+		t.Errorf("删除接口 = %s", got)
+	}
+}
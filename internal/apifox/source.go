@@ -0,0 +1,54 @@
+package apifox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/config"
+)
+
+// Fetcher 读取 API 树形列表、映射与详情，Webhook 处理与同步流程只依赖这部分能力
+type Fetcher interface {
+	// GetApiTreeList 获取项目的 API 树形列表
+	GetApiTreeList(ctx context.Context) (*ApiTreeListResponse, error)
+	// GetApiMappings 获取以 "method path" 为键的 API 基本信息
+	GetApiMappings(ctx context.Context) (map[string]ApiBasic, error)
+	// GetApiDetail 获取单个 API 的详细信息，apiKey 格式为 "apiDetail.ID"
+	GetApiDetail(ctx context.Context, apiKey string) (*ApiDetailResponse, error)
+}
+
+// Source Apifox 数据来源，网页端接口（Client）与官方 Open API（OpenAPIClient）两种实现对调用方透明；
+// 测试与本地演示可使用 apifoxtest 包中的内存实现
+type Source interface {
+	Fetcher
+
+	// GetConfig 返回客户端配置
+	GetConfig() *config.ApifoxConfig
+
+	// AuthState 返回当前的凭证状态
+	AuthState() AuthState
+	// OnAuthFailure 注册凭证失效时的回调
+	OnAuthFailure(fn func(state AuthState))
+	// SetAuthorization 热替换凭证
+	SetAuthorization(token string)
+	// VerifyToken 用候选凭证请求一次 Apifox，不影响当前凭证及其状态
+	VerifyToken(ctx context.Context, token string) error
+}
+
+var (
+	_ Source = (*Client)(nil)
+	_ Source = (*OpenAPIClient)(nil)
+)
+
+// NewSource 根据配置的模式创建数据来源
+func NewSource(cfg *config.ApifoxConfig, logger *logrus.Logger) (Source, error) {
+	switch cfg.Mode {
+	case "", config.ApifoxModeWeb:
+		return NewClient(cfg, logger), nil
+	case config.ApifoxModeOpenAPI:
+		return NewOpenAPIClient(cfg, logger), nil
+	default:
+		return nil, fmt.Errorf("不支持的 Apifox 模式: %s", cfg.Mode)
+	}
+}
@@ -0,0 +1,50 @@
+package apifox
+
+// 接口状态
+const (
+	StatusDesigning   = "designing"
+	StatusPending     = "pending"
+	StatusDeveloping  = "developing"
+	StatusIntegrating = "integrating"
+	StatusTesting     = "testing"
+	StatusTested      = "tested"
+	StatusReleased    = "released"
+	StatusObsolete    = "obsolete"
+	StatusDeprecated  = "deprecated"
+	StatusException   = "exception"
+)
+
+// StatusLabel 返回接口状态的中文名称，未知状态原样返回
+func StatusLabel(status string) string {
+	switch status {
+	case StatusDesigning:
+		return "设计中"
+	case StatusPending:
+		return "待确定"
+	case StatusDeveloping:
+		return "开发中"
+	case StatusIntegrating:
+		return "联调中"
+	case StatusTesting:
+		return "测试中"
+	case StatusTested:
+		return "已测完"
+	case StatusReleased:
+		return "已发布"
+	case StatusObsolete:
+		return "将废弃"
+	case StatusDeprecated:
+		return "已废弃"
+	case StatusException:
+		return "有异常"
+	case "":
+		return "无"
+	default:
+		return status
+	}
+}
+
+// IsDeprecatedStatus 是否为将废弃或已废弃状态
+func IsDeprecatedStatus(status string) bool {
+	return status == StatusObsolete || status == StatusDeprecated
+}
@@ -0,0 +1,166 @@
+package apifox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// 树形列表节点类型
+const (
+	TreeTypeApi          = "apiDetail"
+	TreeTypeApiFolder    = "apiDetailFolder"
+	TreeTypeDoc          = "doc"
+	TreeTypeSchema       = "schema"
+	TreeTypeSchemaFolder = "schemaFolder"
+)
+
+// ApiTree 解码后的树形列表，节点之间带有父子链接；
+// 客户端、同步服务和 Webhook 处理都通过这里的遍历方法读取树形列表
+type ApiTree struct {
+	Roots []*ApiTreeItem
+}
+
+// NewApiTree 由根节点构建树形列表并建立父节点链接
+func NewApiTree(roots []*ApiTreeItem) *ApiTree {
+	tree := &ApiTree{Roots: roots}
+	tree.link()
+	return tree
+}
+
+// UnmarshalJSON 解码 Apifox 返回的节点数组并建立父节点链接
+func (t *ApiTree) UnmarshalJSON(data []byte) error {
+	var roots []*ApiTreeItem
+	if err := json.Unmarshal(data, &roots); err != nil {
+		return err
+	}
+	t.Roots = roots
+	t.link()
+	return nil
+}
+
+// MarshalJSON 编码为与 Apifox 一致的节点数组
+func (t ApiTree) MarshalJSON() ([]byte, error) {
+	if t.Roots == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(t.Roots)
+}
+
+// link 为所有节点设置父节点
+func (t *ApiTree) link() {
+	var walk func(parent *ApiTreeItem, items []*ApiTreeItem)
+	walk = func(parent *ApiTreeItem, items []*ApiTreeItem) {
+		for _, item := range items {
+			item.Parent = parent
+			walk(item, item.Children)
+		}
+	}
+	walk(nil, t.Roots)
+}
+
+// Walk 按深度优先顺序访问所有节点，fn 返回 false 时不再访问该节点的子节点
+func (t *ApiTree) Walk(fn func(item *ApiTreeItem) bool) {
+	var walk func(items []*ApiTreeItem)
+	walk = func(items []*ApiTreeItem) {
+		for _, item := range items {
+			if fn(item) {
+				walk(item.Children)
+			}
+		}
+	}
+	walk(t.Roots)
+}
+
+// Apis 返回所有 API 节点，包括各级目录下的
+func (t *ApiTree) Apis() []*ApiTreeItem {
+	var apis []*ApiTreeItem
+	t.Walk(func(item *ApiTreeItem) bool {
+		if item.IsApi() {
+			apis = append(apis, item)
+		}
+		return true
+	})
+	return apis
+}
+
+// FindByKey 根据 key 查找节点
+func (t *ApiTree) FindByKey(key string) (*ApiTreeItem, bool) {
+	var found *ApiTreeItem
+	t.Walk(func(item *ApiTreeItem) bool {
+		if found == nil && item.Key == key {
+			found = item
+		}
+		return found == nil
+	})
+	return found, found != nil
+}
+
+// FindApiByName 根据名称查找 API 节点，同名时返回遍历顺序中的第一个
+func (t *ApiTree) FindApiByName(name string) (*ApiTreeItem, bool) {
+	for _, item := range t.Apis() {
+		if item.Name == name {
+			return item, true
+		}
+	}
+	return nil, false
+}
+
+// Mappings 返回以 "method path" 为键的 API 基本信息，方法或路径为空的 API 不包含在内
+func (t *ApiTree) Mappings() map[string]ApiBasic {
+	mappings := make(map[string]ApiBasic)
+	for _, item := range t.Apis() {
+		if item.Api == nil || item.Api.Method == "" || item.Api.Path == "" {
+			continue
+		}
+		mappings[strings.ToLower(item.Api.Method)+" "+item.Api.Path] = *item.Api
+	}
+	return mappings
+}
+
+// ApiTreeItem 树形列表节点：目录、API、文档或数据模型
+type ApiTreeItem struct {
+	Key      string         `json:"key"`
+	Type     string         `json:"type"`
+	Name     string         `json:"name"`
+	Children []*ApiTreeItem `json:"children,omitempty"`
+	Api      *ApiBasic      `json:"api,omitempty"`
+	Folder   *ApiFolder     `json:"folder,omitempty"`
+
+	// Parent 父节点，根节点为 nil
+	Parent *ApiTreeItem `json:"-"`
+}
+
+// IsApi 是否为 API 节点
+func (i *ApiTreeItem) IsApi() bool {
+	return i.Type == TreeTypeApi && i.Key != ""
+}
+
+// IsFolder 是否为目录节点
+func (i *ApiTreeItem) IsFolder() bool {
+	return strings.HasSuffix(i.Type, "Folder")
+}
+
+// FolderPath 返回从根目录到该节点所在目录的名称
+func (i *ApiTreeItem) FolderPath() []string {
+	var path []string
+	for parent := i.Parent; parent != nil; parent = parent.Parent {
+		path = append([]string{parent.Name}, path...)
+	}
+	return path
+}
+
+// Fingerprint 计算 API 元数据（api 字段）的指纹，元数据中的更新时间、编辑人、路径等任一变化都会使指纹改变；
+// 没有 api 字段时返回空字符串
+func (i *ApiTreeItem) Fingerprint() string {
+	if i.Api == nil {
+		return ""
+	}
+	data, err := json.Marshal(i.Api)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,89 @@
+package apifox
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const treeListJSON = `{"success":true,"data":[
+	{"key":"apiDetail.1","type":"apiDetail","name":"根接口","api":{"id":1,"method":"get","path":"/ping"}},
+	{"key":"apiDetailFolder.10","type":"apiDetailFolder","name":"用户","folder":{"id":10,"name":"用户"},"children":[
+		{"key":"apiDetail.2","type":"apiDetail","name":"获取用户","api":{"id":2,"method":"GET","path":"/users/{id}","responsibleId":7}},
+		{"key":"apiDetailFolder.11","type":"apiDetailFolder","name":"管理","children":[
+			{"key":"apiDetail.3","type":"apiDetail","name":"删除用户","api":{"id":3,"method":"delete","path":"/users/{id}","updatedAt":"t1"}}
+		]}
+	]},
+	{"key":"doc.5","type":"doc","name":"说明"},
+	{"key":"apiDetail.4","type":"apiDetail","name":"草稿","api":{"id":4,"method":"","path":""}}
+]}`
+
+func decodeTree(t *testing.T) *ApiTree {
+	t.Helper()
+
+	var resp ApiTreeListResponse
+	if err := json.Unmarshal([]byte(treeListJSON), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return &resp.Data
+}
+
+// TestApiTreeApis 嵌套在各级目录下的 API 都应被找到，并带有父节点链接
+func TestApiTreeApis(t *testing.T) {
+	tree := decodeTree(t)
+
+	var keys []string
+	for _, item := range tree.Apis() {
+		keys = append(keys, item.Key)
+	}
+	if want := []string{"apiDetail.1", "apiDetail.2", "apiDetail.3", "apiDetail.4"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Apis() = %v, want %v", keys, want)
+	}
+
+	item, ok := tree.FindByKey("apiDetail.3")
+	if !ok {
+		t.Fatal("FindByKey(apiDetail.3) not found")
+	}
+	if got := item.FolderPath(); !reflect.DeepEqual(got, []string{"用户", "管理"}) {
+		t.Errorf("FolderPath() = %v", got)
+	}
+	if item.Parent == nil || !item.Parent.IsFolder() {
+		t.Errorf("Parent = %+v", item.Parent)
+	}
+
+	if item, ok := tree.FindApiByName("获取用户"); !ok || item.Api.ID != 2 {
+		t.Errorf("FindApiByName = %+v, %v", item, ok)
+	}
+	if _, ok := tree.FindApiByName("说明"); ok {
+		t.Error("文档节点不应作为 API 返回")
+	}
+}
+
+// TestApiTreeMappings 映射以小写方法加路径为键，跳过方法或路径为空的 API
+func TestApiTreeMappings(t *testing.T) {
+	mappings := decodeTree(t).Mappings()
+
+	if len(mappings) != 3 {
+		t.Errorf("len(Mappings()) = %d, want 3: %v", len(mappings), mappings)
+	}
+	if basic := mappings["get /users/{id}"]; basic.ID != 2 || basic.ResponsibleID != 7 {
+		t.Errorf("get /users/{id} = %+v", basic)
+	}
+}
+
+// TestApiTreeFingerprint 元数据变化时指纹改变，没有 api 字段时为空
+func TestApiTreeFingerprint(t *testing.T) {
+	tree := decodeTree(t)
+	item, _ := tree.FindByKey("apiDetail.3")
+
+	before := item.Fingerprint()
+	item.Api.UpdatedAt = "t2"
+	if before == "" || before == item.Fingerprint() {
+		t.Errorf("fingerprint %q should change after update", before)
+	}
+
+	folder, _ := tree.FindByKey("apiDetailFolder.10")
+	if folder.Fingerprint() != "" {
+		t.Error("目录节点的指纹应为空")
+	}
+}
@@ -0,0 +1,179 @@
+package apifox
+
+import (
+	"sort"
+	"strings"
+)
+
+// Apifox Webhook 事件类型
+const (
+	EventApiCreated = "API_CREATED"
+	EventApiUpdated = "API_UPDATED"
+	EventApiDeleted = "API_DELETED"
+
+	EventDocCreated = "DOC_CREATED"
+	EventDocUpdated = "DOC_UPDATED"
+	EventDocDeleted = "DOC_DELETED"
+
+	EventSchemaCreated = "SCHEMA_CREATED"
+	EventSchemaUpdated = "SCHEMA_UPDATED"
+	EventSchemaDeleted = "SCHEMA_DELETED"
+
+	EventFolderCreated = "FOLDER_CREATED"
+	EventFolderUpdated = "FOLDER_UPDATED"
+	EventFolderDeleted = "FOLDER_DELETED"
+
+	EventTestCaseCreated = "TEST_CASE_CREATED"
+	EventTestCaseUpdated = "TEST_CASE_UPDATED"
+	EventTestCaseDeleted = "TEST_CASE_DELETED"
+
+	EventBranchMerged = "BRANCH_MERGED"
+)
+
+// 事件所属的资源类别
+const (
+	CategoryApi      = "api"
+	CategoryDoc      = "doc"
+	CategorySchema   = "schema"
+	CategoryFolder   = "folder"
+	CategoryTestCase = "test_case"
+	CategoryBranch   = "branch"
+)
+
+// 事件动作
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+	ActionMerged  = "merged"
+)
+
+// WebhookEventInfo Webhook 事件的分类信息
+type WebhookEventInfo struct {
+	Event    string `json:"event"`
+	Category string `json:"category"`
+	Action   string `json:"action"`
+}
+
+// webhookEvents 已知事件及其分类，Apifox 不同版本对同一事件的命名不完全一致，这里一并收录
+var webhookEvents = map[string]WebhookEventInfo{
+	EventApiCreated: {Category: CategoryApi, Action: ActionCreated},
+	EventApiUpdated: {Category: CategoryApi, Action: ActionUpdated},
+	EventApiDeleted: {Category: CategoryApi, Action: ActionDeleted},
+
+	EventDocCreated:    {Category: CategoryDoc, Action: ActionCreated},
+	EventDocUpdated:    {Category: CategoryDoc, Action: ActionUpdated},
+	EventDocDeleted:    {Category: CategoryDoc, Action: ActionDeleted},
+	"MARKDOWN_CREATED": {Category: CategoryDoc, Action: ActionCreated},
+	"MARKDOWN_UPDATED": {Category: CategoryDoc, Action: ActionUpdated},
+	"MARKDOWN_DELETED": {Category: CategoryDoc, Action: ActionDeleted},
+
+	EventSchemaCreated:    {Category: CategorySchema, Action: ActionCreated},
+	EventSchemaUpdated:    {Category: CategorySchema, Action: ActionUpdated},
+	EventSchemaDeleted:    {Category: CategorySchema, Action: ActionDeleted},
+	"DATA_SCHEMA_CREATED": {Category: CategorySchema, Action: ActionCreated},
+	"DATA_SCHEMA_UPDATED": {Category: CategorySchema, Action: ActionUpdated},
+	"DATA_SCHEMA_DELETED": {Category: CategorySchema, Action: ActionDeleted},
+
+	EventFolderCreated:   {Category: CategoryFolder, Action: ActionCreated},
+	EventFolderUpdated:   {Category: CategoryFolder, Action: ActionUpdated},
+	EventFolderDeleted:   {Category: CategoryFolder, Action: ActionDeleted},
+	"API_FOLDER_CREATED": {Category: CategoryFolder, Action: ActionCreated},
+	"API_FOLDER_UPDATED": {Category: CategoryFolder, Action: ActionUpdated},
+	"API_FOLDER_DELETED": {Category: CategoryFolder, Action: ActionDeleted},
+
+	EventTestCaseCreated: {Category: CategoryTestCase, Action: ActionCreated},
+	EventTestCaseUpdated: {Category: CategoryTestCase, Action: ActionUpdated},
+	EventTestCaseDeleted: {Category: CategoryTestCase, Action: ActionDeleted},
+	"API_CASE_CREATED":   {Category: CategoryTestCase, Action: ActionCreated},
+	"API_CASE_UPDATED":   {Category: CategoryTestCase, Action: ActionUpdated},
+	"API_CASE_DELETED":   {Category: CategoryTestCase, Action: ActionDeleted},
+
+	EventBranchMerged:       {Category: CategoryBranch, Action: ActionMerged},
+	"SPRINT_BRANCH_MERGED":  {Category: CategoryBranch, Action: ActionMerged},
+	"PROJECT_BRANCH_MERGED": {Category: CategoryBranch, Action: ActionMerged},
+}
+
+// ClassifyWebhookEvent 返回事件的分类信息，未知事件返回 false
+func ClassifyWebhookEvent(event string) (WebhookEventInfo, bool) {
+	info, ok := webhookEvents[strings.ToUpper(strings.TrimSpace(event))]
+	if !ok {
+		return WebhookEventInfo{Event: event}, false
+	}
+	info.Event = event
+	return info, true
+}
+
+// CategoryLabel 返回资源类别的中文名称
+func CategoryLabel(category string) string {
+	switch category {
+	case CategoryApi:
+		return "接口"
+	case CategoryDoc:
+		return "文档"
+	case CategorySchema:
+		return "数据模型"
+	case CategoryFolder:
+		return "目录"
+	case CategoryTestCase:
+		return "测试用例"
+	case CategoryBranch:
+		return "分支"
+	default:
+		return "资源"
+	}
+}
+
+// ActionLabel 返回事件动作的中文名称
+func ActionLabel(action string) string {
+	switch action {
+	case ActionCreated:
+		return "新建"
+	case ActionUpdated:
+		return "修改"
+	case ActionDeleted:
+		return "删除"
+	case ActionMerged:
+		return "合并"
+	default:
+		return "变更"
+	}
+}
+
+// ParseWebhookFields 将 webhook 内容中 "键：值" 形式的行解析为映射
+func ParseWebhookFields(content string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		idx := strings.Index(line, "：")
+		if idx <= 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:idx])
+		value := strings.TrimSpace(line[idx+len("："):])
+		if key != "" {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// ResourceName 从 webhook 字段中找出资源名称，例如 "文档名称"、"数据模型名称"
+func ResourceName(fields map[string]string, fallback string) string {
+	for _, key := range []string{"接口名称", "文档名称", "数据模型名称", "模型名称", "目录名称", "用例名称", "测试用例名称", "分支名称", "名称"} {
+		if v := fields[key]; v != "" {
+			return v
+		}
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if strings.HasSuffix(key, "名称") && fields[key] != "" {
+			return fields[key]
+		}
+	}
+	return fallback
+}
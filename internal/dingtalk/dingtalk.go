@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/sirupsen/logrus"
 	"github.com/xhy/api-pulse/internal/apifox"
+	"github.com/xhy/api-pulse/internal/metrics"
 )
 
 // NotifyService 钉钉通知服务
@@ -16,6 +20,23 @@ type NotifyService struct {
 	webhookURL string
 	client     *resty.Client
 	logger     *logrus.Logger
+
+	statusMutex sync.RWMutex
+	status      Status
+}
+
+// Status 通知通道的最近发送状态
+type Status struct {
+	Configured  bool      `json:"configured"`
+	LastSentAt  time.Time `json:"last_sent_at,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitempty"`
+}
+
+// robotResponse 钉钉机器人接口的响应，HTTP 200 时也可能通过 errcode 拒绝消息
+type robotResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
 }
 
 // MarkdownMessage 钉钉 markdown 消息结构
@@ -38,14 +59,64 @@ func NewNotifyService(webhookURL string, logger *logrus.Logger) *NotifyService {
 		webhookURL: webhookURL,
 		client:     resty.New(),
 		logger:     logger,
+		status: Status{
+			Configured: webhookURL != "",
+		},
+	}
+}
+
+// Status 返回通知通道的最近发送状态
+func (s *NotifyService) Status() Status {
+	s.statusMutex.RLock()
+	defer s.statusMutex.RUnlock()
+
+	return s.status
+}
+
+// recordResult 记录一次发送结果
+func (s *NotifyService) recordResult(err error) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	if err != nil {
+		s.status.LastError = err.Error()
+		s.status.LastErrorAt = time.Now()
+		return
 	}
+	s.status.LastSentAt = time.Now()
 }
 
 // SendApiChangedNotification 发送 API 变更通知
 func (s *NotifyService) SendApiChangedNotification(diff apifox.ApiDiff) error {
-	// 构建 Markdown 消息内容
-	title := "API 变更通知"
-	text := s.buildApiDiffMarkdown(diff)
+	// 没有实质性变更的差异不发送通知
+	if !diff.HasChanges() {
+		s.logger.WithField("api_key", diff.ApiKey).Debug("差异为空，跳过 API 变更通知")
+		return nil
+	}
+
+	// 废弃和负责人交接使用单独的通知类型，便于在群里区分
+	kind, title := "api_changed", "API 变更通知"
+	switch {
+	case diff.Deprecated:
+		kind, title = "api_deprecated", "API 废弃通知"
+	case diff.Handover:
+		kind, title = "api_handover", "API 负责人变更通知"
+	}
+
+	if err := s.sendMarkdown(kind, title, RenderApiDiffMarkdown(diff)); err != nil {
+		return err
+	}
+
+	s.logger.WithField("kind", kind).Info("成功发送 API 变更通知到钉钉")
+	return nil
+}
+
+// sendMarkdown 发送 Markdown 消息到钉钉，kind 为通知类型，用于指标统计
+func (s *NotifyService) sendMarkdown(kind, title, text string) (err error) {
+	defer func() {
+		metrics.ObserveNotification("dingtalk", kind, err)
+		s.recordResult(err)
+	}()
 
 	message := MarkdownMessage{
 		MsgType: "markdown",
@@ -79,18 +150,43 @@ func (s *NotifyService) SendApiChangedNotification(diff apifox.ApiDiff) error {
 		return fmt.Errorf("钉钉服务器返回错误: %s", resp.Status())
 	}
 
-	s.logger.Info("成功发送 API 变更通知到钉钉")
+	// 关键字不匹配、签名错误、限流等情况下钉钉仍返回 200，需要检查 errcode
+	var result robotResponse
+	if err := json.Unmarshal(resp.Body(), &result); err == nil && result.ErrCode != 0 {
+		s.logger.WithFields(logrus.Fields{
+			"errcode": result.ErrCode,
+			"errmsg":  result.ErrMsg,
+		}).Error("钉钉拒绝了消息")
+		return fmt.Errorf("钉钉拒绝了消息: %d %s", result.ErrCode, result.ErrMsg)
+	}
+
 	return nil
 }
 
-// buildApiDiffMarkdown 构建 API 差异的 Markdown 内容
-func (s *NotifyService) buildApiDiffMarkdown(diff apifox.ApiDiff) string {
+// RenderApiDiffMarkdown 构建 API 差异的 Markdown 内容，与发送到钉钉的通知正文一致
+func RenderApiDiffMarkdown(diff apifox.ApiDiff) string {
 	var buffer bytes.Buffer
 
-	buffer.WriteString(fmt.Sprintf("### API变更通知: %s\n\n", diff.Name))
+	// 标题保留 "API变更通知" 关键字，避免被只配置了原有关键字的机器人拦截
+	switch {
+	case diff.Deprecated:
+		buffer.WriteString(fmt.Sprintf("### ⚠️ API变更通知 · 接口已废弃: %s\n\n", diff.Name))
+		buffer.WriteString(fmt.Sprintf("> <font color=#d93026>**接口状态变为「%s」，调用方请尽快迁移**</font>\n\n", apifox.StatusLabel(diff.NewStatus)))
+	case diff.Handover:
+		buffer.WriteString(fmt.Sprintf("### 👤 API变更通知 · 负责人变更: %s\n\n", diff.Name))
+		buffer.WriteString(fmt.Sprintf("> <font color=#1a73e8>**负责人: %d → %d**</font>\n\n", diff.OldResponsibleID, diff.NewResponsibleID))
+	default:
+		buffer.WriteString(fmt.Sprintf("### API变更通知: %s\n\n", diff.Name))
+	}
 	buffer.WriteString(fmt.Sprintf("**接口ID:** %d\n\n", diff.ApiID))
 	buffer.WriteString(fmt.Sprintf("**请求方法:** %s\n\n", diff.Method))
 
+	// 基本信息变更
+	if diff.MetadataDiff {
+		buffer.WriteString("#### 基本信息变更\n\n")
+		buffer.WriteString(fmt.Sprintf("```\n%s\n```\n\n", diff.MetadataDetail))
+	}
+
 	// 方法变更
 	if diff.MethodDiff {
 		buffer.WriteString("#### 请求方法变更\n\n")
@@ -142,6 +238,12 @@ func (s *NotifyService) buildApiDiffMarkdown(diff apifox.ApiDiff) string {
 		buffer.WriteString(fmt.Sprintf("```\n%s\n```\n\n", diff.ResponsesDetail))
 	}
 
+	// 鉴权变更
+	if diff.AuthDiff {
+		buffer.WriteString("#### 鉴权变更\n\n")
+		buffer.WriteString(fmt.Sprintf("```\n%s\n```\n\n", diff.AuthDetail))
+	}
+
 	// 修改者信息
 	buffer.WriteString(fmt.Sprintf("**修改者:** %s\n\n", diff.ModifierName))
 	buffer.WriteString(fmt.Sprintf("**修改时间:** %s\n\n", diff.ModifiedTime))
@@ -151,42 +253,10 @@ func (s *NotifyService) buildApiDiffMarkdown(diff apifox.ApiDiff) string {
 
 // SendApiCreatedNotification 发送 API 创建通知
 func (s *NotifyService) SendApiCreatedNotification(diff apifox.ApiDiff) error {
-	// 构建 Markdown 消息内容
-	title := "API 创建通知"
-	text := s.buildApiCreatedMarkdown(diff)
-
-	message := MarkdownMessage{
-		MsgType: "markdown",
-	}
-	message.Markdown.Title = title
-	message.Markdown.Text = text
-	message.At.IsAtAll = false
-
-	// 将消息序列化为 JSON
-	jsonData, err := json.Marshal(message)
-	if err != nil {
-		s.logger.WithError(err).Error("序列化钉钉消息失败")
-		return err
-	}
-
-	// 发送请求
-	resp, err := s.client.R().
-		SetHeader("Content-Type", "application/json").
-		SetBody(jsonData).
-		Post(s.webhookURL)
-
-	if err != nil {
-		s.logger.WithError(err).Error("发送钉钉通知失败")
+	if err := s.sendMarkdown("api_created", "API 创建通知", s.buildApiCreatedMarkdown(diff)); err != nil {
 		return err
 	}
 
-	if resp.StatusCode() != 200 {
-		s.logger.WithField("status", resp.Status()).
-			WithField("response", string(resp.Body())).
-			Error("钉钉服务器返回错误")
-		return fmt.Errorf("钉钉服务器返回错误: %s", resp.Status())
-	}
-
 	s.logger.Info("成功发送 API 创建通知到钉钉")
 	return nil
 }
@@ -205,6 +275,214 @@ func (s *NotifyService) buildApiCreatedMarkdown(diff apifox.ApiDiff) string {
 	return buffer.String()
 }
 
+// SendApiDeletedNotification 发送 API 删除通知
+func (s *NotifyService) SendApiDeletedNotification(diff apifox.ApiDiff) error {
+	if err := s.sendMarkdown("api_deleted", "API 删除通知", s.buildApiDeletedMarkdown(diff)); err != nil {
+		return err
+	}
+
+	s.logger.Info("成功发送 API 删除通知到钉钉")
+	return nil
+}
+
+// buildApiDeletedMarkdown 构建 API 删除的 Markdown 内容
+func (s *NotifyService) buildApiDeletedMarkdown(diff apifox.ApiDiff) string {
+	var buffer bytes.Buffer
+
+	// 标题保留 "API变更通知" 关键字，避免被只配置了原有关键字的机器人拦截
+	buffer.WriteString(fmt.Sprintf("### 🗑 API变更通知 · 接口已删除: %s\n\n", diff.Name))
+	buffer.WriteString(fmt.Sprintf("**接口ID:** %d\n\n", diff.ApiID))
+	buffer.WriteString(fmt.Sprintf("**请求方法:** %s\n\n", strings.ToUpper(diff.OldMethod)))
+	buffer.WriteString(fmt.Sprintf("**API路径:** `%s`\n\n", diff.OldPath))
+	buffer.WriteString(fmt.Sprintf("**删除者:** %s\n\n", diff.ModifierName))
+	buffer.WriteString(fmt.Sprintf("**删除时间:** %s\n\n", diff.ModifiedTime))
+
+	return buffer.String()
+}
+
+// SendAuthFailureNotification 发送 Apifox 凭证失效告警
+func (s *NotifyService) SendAuthFailureNotification(state apifox.AuthState) error {
+	if err := s.sendMarkdown("auth_failure", "Apifox 凭证失效", s.buildAuthFailureMarkdown(state)); err != nil {
+		return err
+	}
+
+	s.logger.Info("成功发送 Apifox 凭证失效告警到钉钉")
+	return nil
+}
+
+// buildAuthFailureMarkdown 构建凭证失效告警的 Markdown 内容
+func (s *NotifyService) buildAuthFailureMarkdown(state apifox.AuthState) string {
+	var buffer bytes.Buffer
+
+	// 标题保留 "API变更通知" 关键字，避免被只配置了原有关键字的机器人拦截
+	buffer.WriteString("### ⚠️ API变更通知 · Apifox 凭证失效\n\n")
+	buffer.WriteString(fmt.Sprintf("**响应状态码:** %d\n\n", state.LastStatus))
+	buffer.WriteString(fmt.Sprintf("**失效时间:** %s\n\n", state.InvalidSince.Format("2006-01-02 15:04:05")))
+	buffer.WriteString("> 凭证更新前无法获取 API 详情，变更通知暂停。")
+	buffer.WriteString("请通过 `PUT /admin/apifox/token` 更新凭证，无需重启服务\n\n")
+
+	return buffer.String()
+}
+
+// SendResourceNotification 发送文档、数据模型、目录、测试用例、分支等资源的变更通知
+func (s *NotifyService) SendResourceNotification(event apifox.ResourceEvent) error {
+	title := fmt.Sprintf("%s%s通知", apifox.CategoryLabel(event.Category), apifox.ActionLabel(event.Action))
+	if err := s.sendMarkdown("resource", title, s.buildResourceMarkdown(event)); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"event":    event.Event,
+		"category": event.Category,
+	}).Info("成功发送资源变更通知到钉钉")
+	return nil
+}
+
+// buildResourceMarkdown 构建资源变更的 Markdown 内容
+func (s *NotifyService) buildResourceMarkdown(event apifox.ResourceEvent) string {
+	var buffer bytes.Buffer
+
+	icon := "📝"
+	switch event.Category {
+	case apifox.CategoryDoc:
+		icon = "📄"
+	case apifox.CategorySchema:
+		icon = "🧩"
+	case apifox.CategoryFolder:
+		icon = "📁"
+	case apifox.CategoryTestCase:
+		icon = "🧪"
+	case apifox.CategoryBranch:
+		icon = "🔀"
+	}
+
+	categoryLabel := apifox.CategoryLabel(event.Category)
+	actionLabel := apifox.ActionLabel(event.Action)
+
+	buffer.WriteString(fmt.Sprintf("### %s API变更通知 · %s%s: %s\n\n", icon, categoryLabel, actionLabel, event.Name))
+	if event.Title != "" {
+		buffer.WriteString(fmt.Sprintf("**事件:** %s\n\n", event.Title))
+	}
+
+	// 除修改者和时间外的其他字段按原样展示
+	keys := make([]string, 0, len(event.Fields))
+	for key := range event.Fields {
+		if key == "修改者" || key == "修改时间" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		buffer.WriteString(fmt.Sprintf("**%s:** %s\n\n", key, event.Fields[key]))
+	}
+
+	if event.Category == apifox.CategoryBranch {
+		buffer.WriteString("> 分支合并可能带来大量接口变更，已触发一次全量同步\n\n")
+	}
+
+	buffer.WriteString(fmt.Sprintf("**修改者:** %s\n\n", event.ModifierName))
+	buffer.WriteString(fmt.Sprintf("**修改时间:** %s\n\n", event.ModifiedTime))
+
+	return buffer.String()
+}
+
+// digestMaxApis 摘要消息中最多列出的接口数量，其余只显示数量
+const digestMaxApis = 20
+
+// SendDigestNotification 发送定时变更摘要
+func (s *NotifyService) SendDigestNotification(digest apifox.Digest) error {
+	if err := s.sendMarkdown("digest", "API 变更摘要", RenderDigestMarkdown(digest)); err != nil {
+		return err
+	}
+
+	s.logger.WithField("total", digest.Total).Info("成功发送 API 变更摘要到钉钉")
+	return nil
+}
+
+// RenderDigestMarkdown 构建变更摘要的 Markdown 内容，与发送到钉钉的通知正文一致
+func RenderDigestMarkdown(digest apifox.Digest) string {
+	var buffer bytes.Buffer
+
+	// 标题保留 "API变更通知" 关键字，避免被只配置了原有关键字的机器人拦截
+	buffer.WriteString("### 📊 API变更通知 · 变更摘要\n\n")
+	if digest.ProjectID != "" {
+		buffer.WriteString(fmt.Sprintf("**项目ID:** %s\n\n", digest.ProjectID))
+	}
+	if digest.ResponsibleID != 0 {
+		buffer.WriteString(fmt.Sprintf("**负责人:** %d\n\n", digest.ResponsibleID))
+	}
+	buffer.WriteString(fmt.Sprintf("**统计区间:** %s ~ %s\n\n", digest.Since, digest.Until))
+
+	if digest.Total == 0 {
+		buffer.WriteString("> 期间没有 API 变更\n\n")
+		return buffer.String()
+	}
+
+	buffer.WriteString(fmt.Sprintf("**变更总数:** %d（涉及 %d 个接口）\n\n", digest.Total, len(digest.Apis)))
+
+	// 按类型和严重程度统计
+	var types []string
+	for _, action := range []string{apifox.ActionCreated, apifox.ActionUpdated, apifox.ActionDeleted} {
+		if n := digest.ByType[action]; n > 0 {
+			types = append(types, fmt.Sprintf("%s %d", apifox.ActionLabel(action), n))
+		}
+	}
+	buffer.WriteString(fmt.Sprintf("**按类型:** %s\n\n", strings.Join(types, " · ")))
+
+	var severities []string
+	for _, severity := range []string{apifox.SeverityHigh, apifox.SeverityMedium, apifox.SeverityLow} {
+		if n := digest.BySeverity[severity]; n > 0 {
+			severities = append(severities, fmt.Sprintf("%s %d", severityLabel(severity), n))
+		}
+	}
+	buffer.WriteString(fmt.Sprintf("**按严重程度:** %s\n\n", strings.Join(severities, " · ")))
+
+	// 受影响的接口
+	buffer.WriteString("#### 受影响的接口\n\n")
+	for i, api := range digest.Apis {
+		if i == digestMaxApis {
+			buffer.WriteString(fmt.Sprintf("- …… 另有 %d 个接口\n", len(digest.Apis)-digestMaxApis))
+			break
+		}
+		actions := make([]string, len(api.ChangeTypes))
+		for j, changeType := range api.ChangeTypes {
+			actions[j] = apifox.ActionLabel(changeType)
+		}
+		line := fmt.Sprintf("%s `%s %s` %s %d 次", api.Name, strings.ToUpper(api.Method), api.Path, strings.Join(actions, "/"), api.Changes)
+		if api.Severity == apifox.SeverityHigh {
+			line = fmt.Sprintf("<font color=#d93026>%s</font>", line)
+		}
+		buffer.WriteString(fmt.Sprintf("- [%s] %s\n", severityLabel(api.Severity), line))
+	}
+	buffer.WriteString("\n")
+
+	// 修改最多的人
+	if len(digest.TopModifiers) > 0 {
+		buffer.WriteString("#### 主要修改者\n\n")
+		for _, modifier := range digest.TopModifiers {
+			buffer.WriteString(fmt.Sprintf("- %s: %d 次\n", modifier.Name, modifier.Changes))
+		}
+		buffer.WriteString("\n")
+	}
+
+	return buffer.String()
+}
+
+// severityLabel 返回严重程度的中文名称
+func severityLabel(severity string) string {
+	switch severity {
+	case apifox.SeverityHigh:
+		return "高"
+	case apifox.SeverityMedium:
+		return "中"
+	case apifox.SeverityLow:
+		return "低"
+	default:
+		return severity
+	}
+}
+
 // ExtractNameTimeFromContent 从 webhook 内容中提取修改者姓名和时间
 func ExtractNameTimeFromContent(content string) (string, string) {
 	lines := strings.Split(content, "\n")
@@ -0,0 +1,98 @@
+// Package dingtalktest 提供测试和本地演示用的钉钉机器人替身，记录收到的每条消息
+package dingtalktest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/xhy/api-pulse/internal/dingtalk"
+)
+
+// Server 模拟钉钉机器人 Webhook 的 HTTP 服务，URL 可直接传给 dingtalk.NewNotifyService
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	messages []dingtalk.MarkdownMessage
+	failNext int
+}
+
+// NewServer 启动记录消息的模拟服务，使用完毕后需调用 Close
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// handle 记录消息并按钉钉的格式返回结果，FailNext 设置的失败次数未用完时返回 errcode 310000
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	var message dingtalk.MarkdownMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		http.Error(w, "解析消息失败", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	failed := s.failNext > 0
+	if failed {
+		s.failNext--
+	} else {
+		s.messages = append(s.messages, message)
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if failed {
+		_, _ = w.Write([]byte(`{"errcode":310000,"errmsg":"keywords not in content"}`))
+		return
+	}
+	_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+}
+
+// FailNext 让接下来 times 条消息被拒绝（HTTP 200，errcode 不为 0），被拒绝的消息不记录
+func (s *Server) FailNext(times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failNext = times
+}
+
+// Messages 返回已收到的消息，按接收顺序
+func (s *Server) Messages() []dingtalk.MarkdownMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]dingtalk.MarkdownMessage(nil), s.messages...)
+}
+
+// Titles 返回已收到消息的标题，按接收顺序
+func (s *Server) Titles() []string {
+	messages := s.Messages()
+	titles := make([]string, 0, len(messages))
+	for _, message := range messages {
+		titles = append(titles, message.Markdown.Title)
+	}
+	return titles
+}
+
+// Find 返回正文包含 substr 的消息
+func (s *Server) Find(substr string) []dingtalk.MarkdownMessage {
+	var found []dingtalk.MarkdownMessage
+	for _, message := range s.Messages() {
+		if strings.Contains(message.Markdown.Text, substr) {
+			found = append(found, message)
+		}
+	}
+	return found
+}
+
+// Reset 清空已收到的消息
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = nil
+}
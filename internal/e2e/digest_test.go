@@ -0,0 +1,93 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/xhy/api-pulse/internal/apifox"
+)
+
+// digestPreview GET /digest/preview 的响应
+type digestPreview struct {
+	Digest   apifox.Digest `json:"digest"`
+	Markdown string        `json:"markdown"`
+}
+
+// TestDigest 摘要汇总上次发送以来的变更，可按负责人预览，发送后下一期从头统计
+func TestDigest(t *testing.T) {
+	h := newHarness(t, userApi(), orderApi())
+
+	updated := h.update(1, func(detail *apifox.ApiDetail) {
+		detail.Parameters.Path[0].Type = "string"
+	})
+	h.webhook(apifox.EventApiUpdated, updated)
+	updated = h.update(1, func(detail *apifox.ApiDetail) {
+		detail.Description = "按 ID 获取用户"
+	})
+	h.webhook(apifox.EventApiUpdated, updated)
+	updated = h.update(2, func(detail *apifox.ApiDetail) {
+		detail.Parameters.Query = append(detail.Parameters.Query,
+			apifox.Parameter{ID: "q2", Name: "size", Type: "integer", Enable: true})
+	})
+	h.webhook(apifox.EventApiUpdated, updated)
+	h.dingtalk.Reset()
+
+	preview := func(query string) digestPreview {
+		t.Helper()
+
+		var result digestPreview
+		resp, err := http.Get(h.server.URL + "/digest/preview" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET /digest/preview%s = %d", query, resp.StatusCode)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	result := preview("")
+	digest := result.Digest
+	if digest.Total != 3 || digest.ByType[apifox.ActionUpdated] != 3 || len(digest.Apis) != 2 {
+		t.Fatalf("摘要 = %+v", digest)
+	}
+	if api := digest.Apis[0]; api.ApiKey != "apiDetail.1" || api.Changes != 2 || api.Severity != apifox.SeverityHigh {
+		t.Errorf("高严重程度的接口应排在最前: %+v", digest.Apis)
+	}
+	if len(digest.TopModifiers) != 1 || digest.TopModifiers[0].Name != "张三" || digest.TopModifiers[0].Changes != 3 {
+		t.Errorf("修改者 = %+v", digest.TopModifiers)
+	}
+	for _, want := range []string{"API变更通知", "变更摘要", "**变更总数:** 3", "获取用户", "订单列表", "张三: 3 次"} {
+		if !strings.Contains(result.Markdown, want) {
+			t.Errorf("摘要缺少 %q:\n%s", want, result.Markdown)
+		}
+	}
+
+	// 按负责人预览
+	if digest := preview("?responsible_id=8").Digest; digest.Total != 1 || digest.Apis[0].ApiKey != "apiDetail.2" {
+		t.Errorf("负责人 8 的摘要 = %+v", digest)
+	}
+
+	if err := h.digest.SendDigest(); err != nil {
+		t.Fatal(err)
+	}
+	text := h.expectMessages("API 变更摘要")[0].Markdown.Text
+	if !strings.Contains(text, "订单列表") {
+		t.Errorf("摘要消息 = %s", text)
+	}
+
+	// 已发送的变更不再计入下一期，没有变更时不发送
+	if digest := preview("").Digest; digest.Total != 0 {
+		t.Errorf("发送后的摘要 = %+v", digest)
+	}
+	if err := h.digest.SendDigest(); err != nil {
+		t.Fatal(err)
+	}
+	h.expectMessages()
+}
@@ -0,0 +1,3 @@
+// Package e2e 端到端测试：以模拟的 Apifox 与钉钉启动完整服务，
+// 覆盖从 /webhook 到获取映射、获取详情、比较差异、发送通知的整个流程，以及定时同步发现的变更
+package e2e
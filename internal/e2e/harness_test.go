@@ -0,0 +1,192 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/internal/apifox"
+	"github.com/xhy/api-pulse/internal/apifox/apifoxtest"
+	"github.com/xhy/api-pulse/internal/dingtalk"
+	"github.com/xhy/api-pulse/internal/dingtalk/dingtalktest"
+	"github.com/xhy/api-pulse/internal/server"
+	"github.com/xhy/api-pulse/internal/service"
+	"github.com/xhy/api-pulse/internal/storage"
+)
+
+// responsibleID 测试服务配置的负责人，只有该负责人的 API 变更才发送通知
+const responsibleID = 7
+
+// harness 按 cmd/apipulse 的方式组装的完整服务，Apifox 与钉钉均为模拟服务
+type harness struct {
+	t *testing.T
+
+	project  *apifoxtest.Project
+	apifox   *apifoxtest.Server
+	dingtalk *dingtalktest.Server
+	store    *storage.ApiStore
+	service  *service.ApiService
+	digest   *service.DigestService
+	server   *httptest.Server
+}
+
+// newHarness 以给定的 API 启动服务并完成初始化，测试结束时自动关闭
+func newHarness(t *testing.T, apis ...apifox.ApiDetail) *harness {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	h := &harness{
+		t:        t,
+		project:  apifoxtest.NewProject(apis...),
+		dingtalk: dingtalktest.NewServer(),
+		store:    storage.NewApiStore(logger),
+	}
+	h.apifox = apifoxtest.NewServer(h.project)
+
+	cfg := h.apifox.Config("token")
+	cfg.ResponsibleId = responsibleID
+	client := apifox.NewClient(cfg, logger)
+
+	diffService := apifox.NewDiffService(logger)
+	notifyService := dingtalk.NewNotifyService(h.dingtalk.URL, logger)
+	h.service = service.NewApiService(logger, client, h.store, diffService)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	apiHandler := server.NewApiNotifyHandler(client, diffService, notifyService, h.store, logger, h.service)
+	queryHandler := server.NewApiQueryHandler(h.store, diffService, logger)
+	adminHandler := server.NewAdminHandler(h.service, client, service.NewJobManager(ctx, logger), "", logger)
+	// 不配置定时发送，测试中直接调用 SendDigest
+	digestService, err := service.NewDigestService(logger, h.store, notifyService, "", cfg.ProjectID, 0, false)
+	if err != nil {
+		t.Fatalf("NewDigestService: %v", err)
+	}
+	h.digest = digestService
+	digestHandler := server.NewDigestHandler(digestService, logger)
+	h.server = httptest.NewServer(server.NewServer(0, apiHandler, queryHandler, adminHandler, digestHandler, logger).Handler())
+
+	t.Cleanup(func() {
+		cancel()
+		h.server.Close()
+		h.apifox.Close()
+		h.dingtalk.Close()
+	})
+
+	if _, failures, _, err := h.service.InitializeApiList(ctx, nil); err != nil || failures > 0 {
+		t.Fatalf("InitializeApiList: failures=%d err=%v", failures, err)
+	}
+	apiHandler.MarkBaselineReady(ctx)
+
+	return h
+}
+
+// webhook 以 Apifox 的格式发送一条接口事件，返回响应状态码
+func (h *harness) webhook(event string, detail apifox.ApiDetail) int {
+	h.t.Helper()
+
+	payload := apifox.WebhookPayload{
+		Event: event,
+		Title: "接口变更",
+		Content: fmt.Sprintf("接口名称：%s\n接口路径：%s %s\n修改者：张三\n修改时间：2024-05-01 10:00:00",
+			detail.Name, strings.ToUpper(detail.Method), detail.Path),
+	}
+	body, _ := json.Marshal(payload)
+
+	resp, err := http.Post(h.server.URL+"/webhook", "application/json", bytes.NewReader(body))
+	if err != nil {
+		h.t.Fatalf("POST /webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+// update 修改模拟 Apifox 中的 API，并像 Apifox 一样刷新更新时间
+func (h *harness) update(id int, fn func(detail *apifox.ApiDetail)) apifox.ApiDetail {
+	h.t.Helper()
+
+	if !h.project.Update(id, func(detail *apifox.ApiDetail) {
+		fn(detail)
+		detail.UpdatedAt = fmt.Sprintf("%s+", detail.UpdatedAt)
+	}) {
+		h.t.Fatalf("API %d 不存在", id)
+	}
+	detail, _ := h.project.Get(id)
+	return detail
+}
+
+// changes 返回某个 API 的变更历史，从新到旧
+func (h *harness) changes(id int) []apifox.ChangeRecord {
+	return h.store.ListChanges(storage.ChangeFilter{ApiKey: fmt.Sprintf("apiDetail.%d", id)})
+}
+
+// expectMessages 断言钉钉收到的消息标题，并清空记录
+func (h *harness) expectMessages(titles ...string) []dingtalk.MarkdownMessage {
+	h.t.Helper()
+
+	messages := h.dingtalk.Messages()
+	got := h.dingtalk.Titles()
+	if len(got) != len(titles) {
+		h.t.Fatalf("钉钉消息 = %q, want %q", got, titles)
+	}
+	for i := range titles {
+		if got[i] != titles[i] {
+			h.t.Fatalf("钉钉消息 = %q, want %q", got, titles)
+		}
+	}
+	h.dingtalk.Reset()
+	return messages
+}
+
+// userApi 负责人为 responsibleID 的示例 API
+func userApi() apifox.ApiDetail {
+	return apifox.ApiDetail{
+		ID:            1,
+		Name:          "获取用户",
+		Type:          "http",
+		Method:        "get",
+		Path:          "/users/{id}",
+		Status:        "developing",
+		ResponsibleID: responsibleID,
+		UpdatedAt:     "2024-05-01T00:00:00.000Z",
+		Parameters: apifox.Parameters{
+			Path: []apifox.Parameter{{ID: "p1", Name: "id", Required: true, Type: "integer", Enable: true}},
+		},
+		Responses: []apifox.Response{{
+			ID:          1,
+			Name:        "成功",
+			Code:        200,
+			ContentType: "json",
+			JsonSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id":   map[string]interface{}{"type": "integer"},
+					"name": map[string]interface{}{"type": "string"},
+				},
+			},
+		}},
+	}
+}
+
+// orderApi 负责人不是 responsibleID 的示例 API
+func orderApi() apifox.ApiDetail {
+	return apifox.ApiDetail{
+		ID:            2,
+		Name:          "订单列表",
+		Type:          "http",
+		Method:        "get",
+		Path:          "/orders",
+		ResponsibleID: responsibleID + 1,
+		UpdatedAt:     "2024-05-01T00:00:00.000Z",
+		Parameters: apifox.Parameters{
+			Query: []apifox.Parameter{{ID: "q1", Name: "page", Type: "integer", Enable: true}},
+		},
+	}
+}
@@ -0,0 +1,75 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/xhy/api-pulse/internal/apifox"
+)
+
+// matchResult GET /apis/match 的响应
+type matchResult struct {
+	Api struct {
+		ApiID int `json:"api_id"`
+	} `json:"api"`
+	PathParams map[string]string `json:"path_params"`
+}
+
+// TestMatchApi 具体的请求路径匹配到接口模板，固定路径段优先于路径参数
+func TestMatchApi(t *testing.T) {
+	me := userApi()
+	me.ID = 3
+	me.Name = "当前用户"
+	me.Path = "/users/me"
+	me.Parameters = apifox.Parameters{}
+	h := newHarness(t, userApi(), me)
+
+	match := func(method, path string) (int, matchResult) {
+		t.Helper()
+
+		var result matchResult
+		resp, err := http.Get(h.server.URL + "/apis/match?method=" + method + "&url=" + url.QueryEscape(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode, result
+	}
+
+	if status, result := match("GET", "/users/42?fields=name"); status != http.StatusOK || result.Api.ApiID != 1 || result.PathParams["id"] != "42" {
+		t.Errorf("GET /users/42 = %d %+v", status, result)
+	}
+	if status, result := match("get", "/users/me"); status != http.StatusOK || result.Api.ApiID != 3 {
+		t.Errorf("GET /users/me = %d %+v", status, result)
+	}
+	if status, _ := match("DELETE", "/users/42"); status != http.StatusNotFound {
+		t.Errorf("DELETE /users/42 = %d, want 404", status)
+	}
+	if status, _ := match("GET", "/orders/1"); status != http.StatusNotFound {
+		t.Errorf("GET /orders/1 = %d, want 404", status)
+	}
+
+	// 路径变化和删除后，索引中的模板随之更新
+	stored, _ := h.store.GetApi("apiDetail.3")
+	stored.ApiPath = "/profile"
+	if err := h.store.SaveApi(stored); err != nil {
+		t.Fatal(err)
+	}
+	if status, result := match("GET", "/users/me"); status != http.StatusOK || result.Api.ApiID != 1 || result.PathParams["id"] != "me" {
+		t.Errorf("移动后 GET /users/me = %d %+v", status, result)
+	}
+	if status, result := match("GET", "/profile"); status != http.StatusOK || result.Api.ApiID != 3 {
+		t.Errorf("GET /profile = %d %+v", status, result)
+	}
+	h.store.DeleteApi("apiDetail.1")
+	if status, _ := match("GET", "/users/42"); status != http.StatusNotFound {
+		t.Errorf("删除后 GET /users/42 = %d, want 404", status)
+	}
+}
@@ -0,0 +1,70 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xhy/api-pulse/internal/apifox"
+)
+
+// TestSyncDetectsChanges 定时同步发现未经 Webhook 通知的变更与新接口，记入变更历史
+func TestSyncDetectsChanges(t *testing.T) {
+	h := newHarness(t, userApi(), orderApi())
+	ctx := context.Background()
+
+	h.update(1, func(detail *apifox.ApiDetail) {
+		detail.Method = "post"
+	})
+	h.project.Put(apifox.ApiDetail{
+		ID:        3,
+		Name:      "创建订单",
+		Method:    "post",
+		Path:      "/orders",
+		UpdatedAt: "2024-05-01T00:00:00.000Z",
+	})
+
+	if err := h.service.FullSyncAllAPIs(ctx, nil); err != nil {
+		t.Fatalf("FullSyncAllAPIs: %v", err)
+	}
+
+	changes := h.changes(1)
+	if len(changes) != 1 || changes[0].Source != apifox.SourceSync || changes[0].Diff == nil || !changes[0].Diff.MethodDiff {
+		t.Fatalf("变更历史 = %+v", changes)
+	}
+	if changes := h.changes(3); len(changes) != 1 || changes[0].ChangeType != apifox.ActionCreated {
+		t.Errorf("新接口变更历史 = %+v", changes)
+	}
+	if changes := h.changes(2); len(changes) != 0 {
+		t.Errorf("未修改的接口出现变更历史: %+v", changes)
+	}
+	if _, ok := h.store.GetApiByPath("post", "/users/{id}"); !ok {
+		t.Error("快照未更新")
+	}
+}
+
+// TestIncrementalSync 增量同步只获取树形列表元数据有变化的接口
+func TestIncrementalSync(t *testing.T) {
+	h := newHarness(t, userApi(), orderApi())
+	ctx := context.Background()
+
+	// 首次同步为全量同步，记录各接口的树形列表指纹
+	if err := h.service.SyncAllAPIs(ctx, nil); err != nil {
+		t.Fatalf("SyncAllAPIs: %v", err)
+	}
+	before := h.project.DetailFetches(2)
+
+	h.update(1, func(detail *apifox.ApiDetail) {
+		detail.Parameters.Path[0].Required = false
+	})
+	if err := h.service.SyncAllAPIs(ctx, nil); err != nil {
+		t.Fatalf("SyncAllAPIs: %v", err)
+	}
+
+	if got := h.project.DetailFetches(2); got != before {
+		t.Errorf("未变化的接口被重新获取: %d -> %d", before, got)
+	}
+	if changes := h.changes(1); len(changes) != 1 || changes[0].Source != apifox.SourceSync {
+		t.Errorf("变更历史 = %+v", changes)
+	}
+	h.expectMessages()
+}
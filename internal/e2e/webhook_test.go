@@ -0,0 +1,260 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/xhy/api-pulse/internal/apifox"
+)
+
+// TestApiCreated 新建接口：发送创建通知，保存快照并记录变更历史
+func TestApiCreated(t *testing.T) {
+	h := newHarness(t, userApi())
+
+	created := apifox.ApiDetail{
+		ID:            3,
+		Name:          "创建用户",
+		Type:          "http",
+		Method:        "post",
+		Path:          "/users",
+		ResponsibleID: responsibleID,
+		UpdatedAt:     "2024-05-01T00:00:00.000Z",
+	}
+	h.project.Put(created)
+
+	if status := h.webhook(apifox.EventApiCreated, created); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	messages := h.expectMessages("API 创建通知")
+	if text := messages[0].Markdown.Text; !strings.Contains(text, "创建用户") || !strings.Contains(text, "`/users`") {
+		t.Errorf("创建通知内容不完整:\n%s", text)
+	}
+	if _, ok := h.store.GetApi("apiDetail.3"); !ok {
+		t.Error("新接口未保存")
+	}
+	if changes := h.changes(3); len(changes) != 1 || changes[0].ChangeType != apifox.ActionCreated || changes[0].Source != apifox.SourceWebhook {
+		t.Errorf("变更历史 = %+v", changes)
+	}
+}
+
+// TestApiUpdated 修改参数与响应：发送变更通知，通知中包含具体的变更内容
+func TestApiUpdated(t *testing.T) {
+	h := newHarness(t, userApi())
+
+	updated := h.update(1, func(detail *apifox.ApiDetail) {
+		detail.Parameters.Query = append(detail.Parameters.Query,
+			apifox.Parameter{ID: "q1", Name: "fields", Type: "string", Enable: true})
+		props := detail.Responses[0].JsonSchema.(map[string]interface{})["properties"].(map[string]interface{})
+		props["email"] = map[string]interface{}{"type": "string"}
+	})
+
+	if status := h.webhook(apifox.EventApiUpdated, updated); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	text := h.expectMessages("API 变更通知")[0].Markdown.Text
+	for _, want := range []string{"获取用户", "参数变更", "fields", "响应变更", "响应结构变更", "张三"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("变更通知缺少 %q:\n%s", want, text)
+		}
+	}
+
+	changes := h.changes(1)
+	if len(changes) != 1 || changes[0].ChangeType != apifox.ActionUpdated || changes[0].FromVersion == 0 {
+		t.Errorf("变更历史 = %+v", changes)
+	}
+	if stored, _ := h.store.GetApi("apiDetail.1"); len(stored.Detail.Parameters.Query) != 1 {
+		t.Errorf("快照未更新: %+v", stored.Detail.Parameters)
+	}
+}
+
+// TestApiUpdatedWithoutChanges 内容未变的修改事件不发送通知，也不记录变更历史
+func TestApiUpdatedWithoutChanges(t *testing.T) {
+	h := newHarness(t, userApi())
+
+	unchanged := h.update(1, func(detail *apifox.ApiDetail) {})
+	if status := h.webhook(apifox.EventApiUpdated, unchanged); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	h.expectMessages()
+	if changes := h.changes(1); len(changes) != 0 {
+		t.Errorf("变更历史 = %+v", changes)
+	}
+}
+
+// TestApiUpdatedCosmeticOnly 只有参数 ID、响应 ID、空列表表示方式变化的修改事件不发送通知，也不产生新版本
+func TestApiUpdatedCosmeticOnly(t *testing.T) {
+	h := newHarness(t, userApi())
+
+	updated := h.update(1, func(detail *apifox.ApiDetail) {
+		detail.Parameters.Path[0].ID = "p2"
+		detail.Parameters.Query = []apifox.Parameter{}
+		detail.Responses[0].ID = 9
+		detail.Tags = []string{}
+	})
+	if status := h.webhook(apifox.EventApiUpdated, updated); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	h.expectMessages()
+	if changes := h.changes(1); len(changes) != 0 {
+		t.Errorf("变更历史 = %+v", changes)
+	}
+	if versions, _ := h.store.GetApiVersions("apiDetail.1"); len(versions) != 1 {
+		t.Errorf("版本数 = %d, want 1", len(versions))
+	}
+}
+
+// TestApiPathRenamed 路径变更：按新路径在映射中找到接口，通知中包含新旧路径
+func TestApiPathRenamed(t *testing.T) {
+	h := newHarness(t, userApi())
+
+	renamed := h.update(1, func(detail *apifox.ApiDetail) {
+		detail.Path = "/members/{id}"
+	})
+
+	if status := h.webhook(apifox.EventApiUpdated, renamed); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	text := h.expectMessages("API 变更通知")[0].Markdown.Text
+	for _, want := range []string{"路径变更", "`/users/{id}`", "`/members/{id}`"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("变更通知缺少 %q:\n%s", want, text)
+		}
+	}
+
+	if _, ok := h.store.GetApiByPath("get", "/members/{id}"); !ok {
+		t.Error("新路径未建立索引")
+	}
+	if _, ok := h.store.GetApiByPath("get", "/users/{id}"); ok {
+		t.Error("旧路径索引未移除")
+	}
+	if changes := h.changes(1); len(changes) != 1 || changes[0].Severity != apifox.SeverityHigh {
+		t.Errorf("变更历史 = %+v", changes)
+	}
+}
+
+// TestPathParamRenamed 只重命名路径参数：按参数重命名通知，不报告路径变更
+func TestPathParamRenamed(t *testing.T) {
+	h := newHarness(t, userApi())
+
+	updated := h.update(1, func(detail *apifox.ApiDetail) {
+		detail.Path = "/users/{userId}"
+		detail.Parameters.Path[0].Name = "userId"
+	})
+	if status := h.webhook(apifox.EventApiUpdated, updated); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	text := h.expectMessages("API 变更通知")[0].Markdown.Text
+	if !strings.Contains(text, "* 重命名: id -> userId") || strings.Contains(text, "路径变更") || strings.Contains(text, "删除") {
+		t.Errorf("通知内容不符合预期:\n%s", text)
+	}
+	if changes := h.changes(1); len(changes) != 1 || changes[0].Severity != apifox.SeverityMedium {
+		t.Errorf("变更历史 = %+v", changes)
+	}
+}
+
+// TestResponsibleFiltering 负责人不匹配的接口只保存快照和变更历史，不发送通知
+func TestResponsibleFiltering(t *testing.T) {
+	h := newHarness(t, userApi(), orderApi())
+
+	updated := h.update(2, func(detail *apifox.ApiDetail) {
+		detail.Parameters.Query[0].Required = true
+	})
+
+	if status := h.webhook(apifox.EventApiUpdated, updated); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	h.expectMessages()
+	if stored, _ := h.store.GetApi("apiDetail.2"); !stored.Detail.Parameters.Query[0].Required {
+		t.Error("快照未更新")
+	}
+	if changes := h.changes(2); len(changes) != 1 || changes[0].ChangeType != apifox.ActionUpdated {
+		t.Errorf("变更历史 = %+v", changes)
+	}
+
+	// 负责人匹配的接口照常通知
+	updated = h.update(1, func(detail *apifox.ApiDetail) {
+		detail.Parameters.Path[0].Type = "string"
+	})
+	if status := h.webhook(apifox.EventApiUpdated, updated); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	h.expectMessages("API 变更通知")
+}
+
+// TestApiDeprecated 状态变为已废弃：发送单独的废弃通知，变更评为高严重程度
+func TestApiDeprecated(t *testing.T) {
+	h := newHarness(t, userApi())
+
+	updated := h.update(1, func(detail *apifox.ApiDetail) {
+		detail.Status = apifox.StatusDeprecated
+	})
+	if status := h.webhook(apifox.EventApiUpdated, updated); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	text := h.expectMessages("API 废弃通知")[0].Markdown.Text
+	for _, want := range []string{"API变更通知", "接口已废弃", "开发中 -> 已废弃"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("废弃通知缺少 %q:\n%s", want, text)
+		}
+	}
+	if changes := h.changes(1); len(changes) != 1 || changes[0].Severity != apifox.SeverityHigh {
+		t.Errorf("变更历史 = %+v", changes)
+	}
+}
+
+// TestApiHandover 接口从配置的负责人交接给他人：新负责人不匹配也发送负责人变更通知，之后的修改不再通知
+func TestApiHandover(t *testing.T) {
+	h := newHarness(t, userApi())
+
+	updated := h.update(1, func(detail *apifox.ApiDetail) {
+		detail.ResponsibleID = responsibleID + 1
+	})
+	if status := h.webhook(apifox.EventApiUpdated, updated); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	text := h.expectMessages("API 负责人变更通知")[0].Markdown.Text
+	if want := fmt.Sprintf("负责人: %d -> %d", responsibleID, responsibleID+1); !strings.Contains(text, want) {
+		t.Errorf("负责人变更通知缺少 %q:\n%s", want, text)
+	}
+
+	updated = h.update(1, func(detail *apifox.ApiDetail) {
+		detail.Parameters.Path[0].Type = "string"
+	})
+	if status := h.webhook(apifox.EventApiUpdated, updated); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	h.expectMessages()
+	if changes := h.changes(1); len(changes) != 2 {
+		t.Errorf("变更历史 = %+v", changes)
+	}
+}
+
+// TestApifoxFailure 获取详情失败时返回 500，不发送通知，快照保持不变
+func TestApifoxFailure(t *testing.T) {
+	h := newHarness(t, userApi())
+
+	updated := h.update(1, func(detail *apifox.ApiDetail) {
+		detail.Path = "/members/{id}"
+	})
+	h.project.FailDetail(1, 1)
+
+	if status := h.webhook(apifox.EventApiUpdated, updated); status != http.StatusInternalServerError {
+		t.Fatalf("status = %d", status)
+	}
+
+	h.expectMessages()
+	if stored, _ := h.store.GetApi("apiDetail.1"); stored.ApiPath != "/users/{id}" {
+		t.Errorf("快照被修改: %s", stored.ApiPath)
+	}
+}
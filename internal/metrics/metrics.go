@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "apipulse"
+
+var (
+	// WebhooksReceived 按事件类型统计收到的 Webhook
+	WebhooksReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhooks_received_total",
+		Help:      "收到的 Webhook 数量，按事件类型区分",
+	}, []string{"event"})
+
+	// WebhookParseFailures 按阶段统计 Webhook 解析失败
+	WebhookParseFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_parse_failures_total",
+		Help:      "Webhook 解析失败次数，stage 为 body（请求体）、content（内容）或 path（接口路径）",
+	}, []string{"stage"})
+
+	// WebhookQueueSize 等待基线就绪后处理的 Webhook 数量
+	WebhookQueueSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "webhook_queue_size",
+		Help:      "启动初始化完成前排队等待处理的 Webhook 数量",
+	})
+
+	// ApifoxRequests 按接口和状态码统计 Apifox 请求
+	ApifoxRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "apifox_requests_total",
+		Help:      "发往 Apifox 的请求数量，status 为 HTTP 状态码或 error",
+	}, []string{"endpoint", "status"})
+
+	// ApifoxRetries 按接口统计 Apifox 请求重试次数
+	ApifoxRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "apifox_retries_total",
+		Help:      "Apifox 请求因网络错误、429 或 5xx 发起的重试次数",
+	}, []string{"endpoint"})
+
+	// ApifoxRequestDuration Apifox 请求耗时
+	ApifoxRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "apifox_request_duration_seconds",
+		Help:      "发往 Apifox 的请求耗时",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30},
+	}, []string{"endpoint"})
+
+	// SyncDuration 全量同步耗时
+	SyncDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sync_duration_seconds",
+		Help:      "全量或增量同步单次执行耗时",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200},
+	})
+
+	// SyncApis 按结果统计同步处理的 API
+	SyncApis = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_apis_total",
+		Help:      "同步处理的 API 数量，outcome 为 updated、unchanged、new、baselined、skipped 或 error",
+	}, []string{"outcome"})
+
+	// Notifications 按通道、通知类型和结果统计发送的通知
+	Notifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "发送的通知数量，result 为 sent 或 failed",
+	}, []string{"channel", "kind", "result"})
+)
+
+// Handler 返回 Prometheus 指标的 HTTP 处理器
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterStoreSize 注册存储中 API 数量的指标，抓取时调用 count 获取当前值
+func RegisterStoreSize(count func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "store_apis",
+		Help:      "存储中的 API 数量",
+	}, func() float64 {
+		return float64(count())
+	})
+}
+
+// ObserveApifoxRequest 记录一次 Apifox 请求，statusCode 为 0 表示请求未得到响应
+func ObserveApifoxRequest(url string, statusCode int, duration time.Duration) {
+	endpoint := ApifoxEndpoint(url)
+	status := "error"
+	if statusCode > 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	ApifoxRequests.WithLabelValues(endpoint, status).Inc()
+	ApifoxRequestDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
+}
+
+// ApifoxEndpoint 将请求 URL 归类为固定的接口名，避免 API ID 造成标签基数膨胀
+func ApifoxEndpoint(url string) string {
+	switch {
+	case strings.Contains(url, "/api-tree-list"):
+		return "api_tree_list"
+	case strings.Contains(url, "/http-apis/"):
+		return "http_api_detail"
+	case strings.Contains(url, "/export-openapi"):
+		return "export_openapi"
+	default:
+		return "other"
+	}
+}
+
+// ObserveNotification 记录一次通知发送结果
+func ObserveNotification(channel, kind string, err error) {
+	result := "sent"
+	if err != nil {
+		result = "failed"
+	}
+	Notifications.WithLabelValues(channel, kind, result).Inc()
+}
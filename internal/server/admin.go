@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/internal/apifox"
+	"github.com/xhy/api-pulse/internal/service"
+)
+
+// 后台任务类型
+const (
+	jobTypeReinit       = "reinit"
+	jobTypeSyncAll      = "sync_all"
+	jobTypeSyncApi      = "sync_api"
+	jobTypeStoreClear   = "store_clear"
+	jobTypeStoreRebuild = "store_rebuild"
+	jobTypeSyncStart    = "sync_loop_start"
+	jobTypeSyncStop     = "sync_loop_stop"
+)
+
+// AdminHandler 管理接口处理器，所有操作以后台任务方式执行
+type AdminHandler struct {
+	apiService   *service.ApiService
+	apifoxClient apifox.Source
+	jobs         *service.JobManager
+	token        string
+	logger       *logrus.Logger
+}
+
+// NewAdminHandler 创建管理接口处理器，token 为空时管理接口不可用
+func NewAdminHandler(apiService *service.ApiService, apifoxClient apifox.Source, jobs *service.JobManager, token string, logger *logrus.Logger) *AdminHandler {
+	return &AdminHandler{
+		apiService:   apiService,
+		apifoxClient: apifoxClient,
+		jobs:         jobs,
+		token:        token,
+		logger:       logger,
+	}
+}
+
+// Enabled 是否已配置管理令牌
+func (h *AdminHandler) Enabled() bool {
+	return h.token != ""
+}
+
+// Authenticate 校验管理令牌，支持 Authorization: Bearer 与 X-Admin-Token 两种方式
+func (h *AdminHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Admin-Token")
+		if auth := r.Header.Get("Authorization"); token == "" && strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			h.logger.WithField("remote_addr", r.RemoteAddr).Warn("管理接口鉴权失败")
+			writeJSONError(w, http.StatusUnauthorized, "管理令牌无效")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Reinit 重新初始化 API 列表
+func (h *AdminHandler) Reinit(w http.ResponseWriter, r *http.Request) {
+	job := h.jobs.Start(jobTypeReinit, func(ctx context.Context, progress *service.Progress) (string, error) {
+		successCount, failureCount, _, err := h.apiService.InitializeApiList(ctx, progress)
+		return fmt.Sprintf("成功 %d 个，失败 %d 个", successCount, failureCount), err
+	})
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// SyncAll 同步所有 API，full=true 时获取全部详情，否则只获取元数据有变化的 API
+func (h *AdminHandler) SyncAll(w http.ResponseWriter, r *http.Request) {
+	sync := h.apiService.SyncAllAPIs
+	if full, _ := strconv.ParseBool(r.URL.Query().Get("full")); full {
+		sync = h.apiService.FullSyncAllAPIs
+	}
+
+	job := h.jobs.Start(jobTypeSyncAll, func(ctx context.Context, progress *service.Progress) (string, error) {
+		if err := sync(ctx, progress); err != nil {
+			return "", err
+		}
+		return "同步完成", nil
+	})
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// SyncApi 同步单个 API
+func (h *AdminHandler) SyncApi(w http.ResponseWriter, r *http.Request) {
+	key := normalizeApiKey(chi.URLParam(r, "key"))
+	job := h.jobs.Start(jobTypeSyncApi, func(ctx context.Context, progress *service.Progress) (string, error) {
+		progress.SetTotal(1)
+		outcome, err := h.apiService.SyncApi(ctx, key)
+		progress.Add(err == nil)
+		return fmt.Sprintf("%s: %s", key, outcome), err
+	})
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// ClearStore 清空 API 存储，变更历史保留
+func (h *AdminHandler) ClearStore(w http.ResponseWriter, r *http.Request) {
+	job := h.jobs.Start(jobTypeStoreClear, func(ctx context.Context, progress *service.Progress) (string, error) {
+		h.apiService.ClearStore()
+		return "存储已清空", nil
+	})
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// RebuildStore 清空 API 存储后重新初始化
+func (h *AdminHandler) RebuildStore(w http.ResponseWriter, r *http.Request) {
+	job := h.jobs.Start(jobTypeStoreRebuild, func(ctx context.Context, progress *service.Progress) (string, error) {
+		successCount, failureCount, _, err := h.apiService.RebuildStore(ctx, progress)
+		return fmt.Sprintf("成功 %d 个，失败 %d 个", successCount, failureCount), err
+	})
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// StartSyncLoop 启动定时同步
+func (h *AdminHandler) StartSyncLoop(w http.ResponseWriter, r *http.Request) {
+	job := h.jobs.Start(jobTypeSyncStart, func(ctx context.Context, progress *service.Progress) (string, error) {
+		h.apiService.StartSync(ctx)
+		return "定时同步已启动", nil
+	})
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// StopSyncLoop 停止定时同步
+func (h *AdminHandler) StopSyncLoop(w http.ResponseWriter, r *http.Request) {
+	job := h.jobs.Start(jobTypeSyncStop, func(ctx context.Context, progress *service.Progress) (string, error) {
+		h.apiService.StopSync()
+		return "定时同步已停止", nil
+	})
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// UpdateApifoxToken 热替换 Apifox 凭证：先用新凭证请求一次 Apifox，
+// 验证通过才替换，随后触发一次同步以补齐凭证失效期间错过的变更
+func (h *AdminHandler) UpdateApifoxToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "请求体格式错误")
+		return
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(req.Token), "Bearer "))
+	if token == "" {
+		writeJSONError(w, http.StatusBadRequest, "token 不能为空")
+		return
+	}
+
+	if err := h.apifoxClient.VerifyToken(r.Context(), token); err != nil {
+		h.logger.WithError(err).Warn("新的 Apifox 凭证验证失败，继续使用原凭证")
+		if errors.Is(err, apifox.ErrUnauthorized) {
+			writeJSONError(w, http.StatusBadRequest, "新的 Apifox 凭证无效")
+		} else {
+			writeJSONError(w, http.StatusBadGateway, err.Error())
+		}
+		return
+	}
+	h.apifoxClient.SetAuthorization(token)
+
+	job := h.jobs.Start(jobTypeSyncAll, func(ctx context.Context, progress *service.Progress) (string, error) {
+		if err := h.apiService.SyncAllAPIs(ctx, progress); err != nil {
+			return "", err
+		}
+		return "同步完成", nil
+	})
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message":    "凭证已更新并验证通过",
+		"auth_state": h.apifoxClient.AuthState(),
+		"sync_job":   job,
+	})
+}
+
+// ListRetries 列出等待重新获取详情的 API
+func (h *AdminHandler) ListRetries(w http.ResponseWriter, r *http.Request) {
+	retries := h.apiService.RetryQueue()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total":   len(retries),
+		"retries": retries,
+	})
+}
+
+// ListJobs 列出后台任务，最新的在前
+func (h *AdminHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	jobs := h.jobs.List()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total": len(jobs),
+		"jobs":  jobs,
+	})
+}
+
+// GetJob 查询单个后台任务的进度
+func (h *AdminHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.jobs.Get(chi.URLParam(r, "id"))
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "任务不存在")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/xhy/api-pulse/internal/apifox"
+	"github.com/xhy/api-pulse/internal/dingtalk"
+)
+
+// versionTimeLayouts from/to 参数支持的时间格式
+var versionTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// DiffRequest POST /diff 的请求体
+type DiffRequest struct {
+	Old          apifox.ApiDetail `json:"old"`
+	New          apifox.ApiDetail `json:"new"`
+	ModifierName string           `json:"modifier_name"`
+	ModifiedTime string           `json:"modified_time"`
+}
+
+// DiffResult 差异比较结果，同时包含结构化差异和通知正文
+type DiffResult struct {
+	From       *apifox.ApiVersion `json:"from,omitempty"`
+	To         *apifox.ApiVersion `json:"to,omitempty"`
+	HasChanges bool               `json:"has_changes"`
+	Diff       *apifox.ApiDiff    `json:"diff"`
+	Markdown   string             `json:"markdown"`
+}
+
+// DiffApiVersions 比较同一 API 的两个历史版本
+// from/to 可以是版本号，也可以是时间（取该时间点及之前的最新版本），
+// 默认比较最新版本与上一个版本
+func (h *ApiQueryHandler) DiffApiVersions(w http.ResponseWriter, r *http.Request) {
+	apiKey := normalizeApiKey(chi.URLParam(r, "key"))
+
+	versions, exists := h.apiStore.GetApiVersions(apiKey)
+	if !exists || len(versions) == 0 {
+		writeJSONError(w, http.StatusNotFound, "未找到对应的 API: "+apiKey)
+		return
+	}
+
+	toVersion := versions[len(versions)-1]
+	fromVersion := toVersion
+	if len(versions) > 1 {
+		fromVersion = versions[len(versions)-2]
+	}
+
+	var err error
+	if value := r.URL.Query().Get("from"); value != "" {
+		if fromVersion, err = resolveVersion(versions, value); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "from 参数无效: "+err.Error())
+			return
+		}
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		if toVersion, err = resolveVersion(versions, value); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "to 参数无效: "+err.Error())
+			return
+		}
+	}
+
+	diff := h.diffService.CompareApis(fromVersion.Detail, toVersion.Detail, "", toVersion.SavedAt)
+
+	writeJSON(w, http.StatusOK, DiffResult{
+		From:       &fromVersion,
+		To:         &toVersion,
+		HasChanges: diff.HasChanges(),
+		Diff:       diff,
+		Markdown:   dingtalk.RenderApiDiffMarkdown(*diff),
+	})
+}
+
+// DiffApiDetails 比较请求体中给出的两份 API 详情，用于预览通知内容
+func (h *ApiQueryHandler) DiffApiDetails(w http.ResponseWriter, r *http.Request) {
+	var req DiffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "解析请求失败: "+err.Error())
+		return
+	}
+
+	modifiedTime := req.ModifiedTime
+	if modifiedTime == "" {
+		modifiedTime = apifox.FormatCurrentTime()
+	}
+
+	diff := h.diffService.CompareApis(req.Old, req.New, req.ModifierName, modifiedTime)
+
+	writeJSON(w, http.StatusOK, DiffResult{
+		HasChanges: diff.HasChanges(),
+		Diff:       diff,
+		Markdown:   dingtalk.RenderApiDiffMarkdown(*diff),
+	})
+}
+
+// resolveVersion 将版本号或时间解析为具体的历史版本
+func resolveVersion(versions []apifox.ApiVersion, value string) (apifox.ApiVersion, error) {
+	if number, err := strconv.Atoi(value); err == nil {
+		for _, v := range versions {
+			if v.Version == number {
+				return v, nil
+			}
+		}
+		return apifox.ApiVersion{}, fmt.Errorf("版本 %d 不存在或已被淘汰", number)
+	}
+
+	// 只给出日期时包含当天的所有版本
+	at, err := parseQueryTime(value, true)
+	if err != nil {
+		return apifox.ApiVersion{}, err
+	}
+
+	// 取该时间点及之前保存的最新版本
+	var found *apifox.ApiVersion
+	for i := range versions {
+		savedAt, err := time.ParseInLocation("2006-01-02 15:04:05", versions[i].SavedAt, time.Local)
+		if err != nil || savedAt.After(at) {
+			continue
+		}
+		found = &versions[i]
+	}
+	if found == nil {
+		return apifox.ApiVersion{}, fmt.Errorf("%s 之前没有保存的版本", value)
+	}
+	return *found, nil
+}
+
+// parseQueryTime 按支持的格式解析时间，只给出日期时 endOfDay 决定取当天的开始还是结束
+func parseQueryTime(value string, endOfDay bool) (time.Time, error) {
+	for _, layout := range versionTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			if layout == "2006-01-02" && endOfDay {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法识别的版本号或时间: %s", value)
+}
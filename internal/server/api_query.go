@@ -0,0 +1,210 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/internal/apifox"
+	"github.com/xhy/api-pulse/internal/storage"
+)
+
+// ApiQueryHandler 只读的 API 目录查询处理器
+type ApiQueryHandler struct {
+	apiStore    *storage.ApiStore
+	diffService *apifox.DiffService
+	logger      *logrus.Logger
+}
+
+// ApiSummary API 列表中的摘要信息
+type ApiSummary struct {
+	ApiKey        string   `json:"api_key"`
+	ApiID         int      `json:"api_id"`
+	Name          string   `json:"name"`
+	Method        string   `json:"method"`
+	ApiPath       string   `json:"api_path"`
+	FolderID      int      `json:"folder_id"`
+	Tags          []string `json:"tags"`
+	Status        string   `json:"status"`
+	ResponsibleID int      `json:"responsible_id"`
+	UpdatedAt     string   `json:"updated_at"`
+	Incomplete    bool     `json:"incomplete,omitempty"`
+}
+
+// NewApiQueryHandler 创建新的 API 目录查询处理器
+func NewApiQueryHandler(apiStore *storage.ApiStore, diffService *apifox.DiffService, logger *logrus.Logger) *ApiQueryHandler {
+	return &ApiQueryHandler{
+		apiStore:    apiStore,
+		diffService: diffService,
+		logger:      logger,
+	}
+}
+
+// ListApis 列出存储的 API，支持按方法、路径前缀、标签、目录和负责人过滤
+func (h *ApiQueryHandler) ListApis(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	method := strings.ToLower(query.Get("method"))
+	pathPrefix := query.Get("path_prefix")
+	tag := query.Get("tag")
+
+	folderID, err := parseOptionalInt(query.Get("folder"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "folder 参数必须为整数")
+		return
+	}
+	responsibleID, err := parseOptionalInt(query.Get("responsible_id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "responsible_id 参数必须为整数")
+		return
+	}
+
+	apis := make([]ApiSummary, 0)
+	for _, apiInfo := range h.apiStore.GetAllApis() {
+		if method != "" && strings.ToLower(apiInfo.Method) != method {
+			continue
+		}
+		if pathPrefix != "" && !strings.HasPrefix(apiInfo.ApiPath, pathPrefix) {
+			continue
+		}
+		if tag != "" && !contains(apiInfo.Detail.Tags, tag) {
+			continue
+		}
+		if folderID != nil && apiInfo.Detail.FolderID != *folderID {
+			continue
+		}
+		if responsibleID != nil && apiInfo.Detail.ResponsibleID != *responsibleID {
+			continue
+		}
+		apis = append(apis, summarizeApi(apiInfo))
+	}
+
+	// 按路径和方法排序，保证输出稳定
+	sort.Slice(apis, func(i, j int) bool {
+		if apis[i].ApiPath != apis[j].ApiPath {
+			return apis[i].ApiPath < apis[j].ApiPath
+		}
+		return apis[i].Method < apis[j].Method
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total": len(apis),
+		"apis":  apis,
+	})
+}
+
+// GetApi 返回指定 API 最新的存储信息
+func (h *ApiQueryHandler) GetApi(w http.ResponseWriter, r *http.Request) {
+	apiKey := normalizeApiKey(chi.URLParam(r, "key"))
+
+	apiInfo, exists := h.apiStore.GetApi(apiKey)
+	if !exists {
+		writeJSONError(w, http.StatusNotFound, "未找到对应的 API: "+apiKey)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, apiInfo)
+}
+
+// MatchApi 将具体的请求（如 method=GET&url=/users/42）匹配到 API，返回 API 信息和路径参数的取值
+func (h *ApiQueryHandler) MatchApi(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	method := strings.ToLower(query.Get("method"))
+	url := query.Get("url")
+	if method == "" || url == "" {
+		writeJSONError(w, http.StatusBadRequest, "需要 method 和 url 参数")
+		return
+	}
+
+	apiInfo, params, exists := h.apiStore.MatchApi(method, url)
+	if !exists {
+		writeJSONError(w, http.StatusNotFound, "未找到匹配的 API: "+strings.ToUpper(method)+" "+url)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"api":         summarizeApi(apiInfo),
+		"path_params": params,
+	})
+}
+
+// GetApiVersions 返回指定 API 的历史版本
+func (h *ApiQueryHandler) GetApiVersions(w http.ResponseWriter, r *http.Request) {
+	apiKey := normalizeApiKey(chi.URLParam(r, "key"))
+
+	versions, exists := h.apiStore.GetApiVersions(apiKey)
+	if !exists {
+		writeJSONError(w, http.StatusNotFound, "未找到对应的 API: "+apiKey)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"api_key":  apiKey,
+		"total":    len(versions),
+		"versions": versions,
+	})
+}
+
+// summarizeApi 提取 API 的摘要信息
+func summarizeApi(apiInfo apifox.StoredApiInfo) ApiSummary {
+	return ApiSummary{
+		ApiKey:        apiInfo.ApiKey,
+		ApiID:         apiInfo.ApiID,
+		Name:          apiInfo.Name,
+		Method:        apiInfo.Method,
+		ApiPath:       apiInfo.ApiPath,
+		FolderID:      apiInfo.Detail.FolderID,
+		Tags:          apiInfo.Detail.Tags,
+		Status:        apiInfo.Detail.Status,
+		ResponsibleID: apiInfo.Detail.ResponsibleID,
+		UpdatedAt:     apiInfo.UpdatedAt,
+		Incomplete:    apiInfo.Incomplete,
+	}
+}
+
+// normalizeApiKey 允许直接使用数字 ID，自动补全为 "apiDetail.ID" 格式
+func normalizeApiKey(key string) string {
+	if _, err := strconv.Atoi(key); err == nil {
+		return "apiDetail." + key
+	}
+	return key
+}
+
+// parseOptionalInt 解析可选的整数查询参数，为空时返回 nil
+func parseOptionalInt(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// contains 检查字符串切片是否包含特定字符串
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSON 以 JSON 格式写出响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError 以 JSON 格式写出错误信息
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{
+		"error": message,
+	})
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"embed"
+	"net/http"
+	"strconv"
+
+	"github.com/xhy/api-pulse/internal/storage"
+)
+
+// dashboardFS 内嵌的看板页面，不依赖任何外部 CDN
+//
+//go:embed web/dashboard.html
+var dashboardFS embed.FS
+
+// Dashboard 返回内嵌的变更看板页面
+func (h *ApiQueryHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	page, err := dashboardFS.ReadFile("web/dashboard.html")
+	if err != nil {
+		h.logger.WithError(err).Error("读取看板页面失败")
+		http.Error(w, "读取看板页面失败", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page)
+}
+
+// ListChanges 查询变更历史，支持按 API、负责人、目录、严重程度、变更类型和时间范围过滤
+func (h *ApiQueryHandler) ListChanges(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := storage.ChangeFilter{
+		Severity:   query.Get("severity"),
+		ChangeType: query.Get("change_type"),
+		Limit:      200,
+	}
+	if key := query.Get("api_key"); key != "" {
+		filter.ApiKey = normalizeApiKey(key)
+	}
+
+	var err error
+	if filter.ResponsibleID, err = parseOptionalInt(query.Get("responsible_id")); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "responsible_id 参数必须为整数")
+		return
+	}
+	if filter.FolderID, err = parseOptionalInt(query.Get("folder")); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "folder 参数必须为整数")
+		return
+	}
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "limit 参数必须为整数")
+			return
+		}
+	}
+	if value := query.Get("since"); value != "" {
+		if filter.Since, err = parseQueryTime(value, false); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "since 参数无效: "+err.Error())
+			return
+		}
+	}
+	if value := query.Get("until"); value != "" {
+		if filter.Until, err = parseQueryTime(value, true); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "until 参数无效: "+err.Error())
+			return
+		}
+	}
+
+	changes := h.apiStore.ListChanges(filter)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total":   len(changes),
+		"changes": changes,
+	})
+}
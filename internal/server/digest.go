@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/internal/dingtalk"
+	"github.com/xhy/api-pulse/internal/service"
+)
+
+// DigestHandler 变更摘要处理器
+type DigestHandler struct {
+	digestService *service.DigestService
+	logger        *logrus.Logger
+}
+
+// NewDigestHandler 创建新的变更摘要处理器
+func NewDigestHandler(digestService *service.DigestService, logger *logrus.Logger) *DigestHandler {
+	return &DigestHandler{
+		digestService: digestService,
+		logger:        logger,
+	}
+}
+
+// Preview 预览下一份摘要截至当前的内容，可用 responsible_id 参数查看其他负责人的摘要（0 表示整个项目）
+func (h *DigestHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	responsibleID, err := parseOptionalInt(r.URL.Query().Get("responsible_id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "responsible_id 参数必须为整数")
+		return
+	}
+
+	digest, next := h.digestService.Preview(responsibleID)
+
+	response := map[string]interface{}{
+		"digest":   digest,
+		"markdown": dingtalk.RenderDigestMarkdown(digest),
+	}
+	if schedule := h.digestService.Schedule(); schedule != nil {
+		response["schedule"] = schedule.String()
+		response["scheduled_at"] = next.Format("2006-01-02 15:04:05")
+	}
+	writeJSON(w, http.StatusOK, response)
+}
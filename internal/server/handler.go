@@ -1,32 +1,40 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/xhy/api-pulse/internal/apifox"
 	"github.com/xhy/api-pulse/internal/dingtalk"
+	"github.com/xhy/api-pulse/internal/metrics"
 	"github.com/xhy/api-pulse/internal/service"
 	"github.com/xhy/api-pulse/internal/storage"
 )
 
 // ApiNotifyHandler Webhook 处理器
 type ApiNotifyHandler struct {
-	apifoxClient  *apifox.Client
+	apifoxClient  apifox.Source
 	diffService   *apifox.DiffService
 	notifyService *dingtalk.NotifyService
 	apiStore      *storage.ApiStore
 	logger        *logrus.Logger
 	apiService    *service.ApiService
+
+	queueMutex    sync.Mutex
+	baselineReady bool
+	pending       []apifox.WebhookPayload
 }
 
 // NewApiNotifyHandler 创建新的 Webhook 处理器
 func NewApiNotifyHandler(
-	apifoxClient *apifox.Client,
+	apifoxClient apifox.Source,
 	diffService *apifox.DiffService,
 	notifyService *dingtalk.NotifyService,
 	apiStore *storage.ApiStore,
@@ -49,6 +57,7 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 	var payload apifox.WebhookPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		h.logger.WithError(err).Error("解析 Webhook 请求体失败")
+		metrics.WebhookParseFailures.WithLabelValues("body").Inc()
 		http.Error(w, "解析请求失败", http.StatusBadRequest)
 		return
 	}
@@ -59,20 +68,47 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 		"content": payload.Content,
 	}).Info("接收到 Webhook")
 
-	// 检查事件类型
-	if payload.Event != "API_UPDATED" && payload.Event != "API_CREATED" {
-		h.logger.WithField("event", payload.Event).Info("忽略非 API 更新/创建事件")
-		w.WriteHeader(http.StatusOK)
+	// 识别事件类型
+	info, known := apifox.ClassifyWebhookEvent(payload.Event)
+	if !known {
+		// 未知事件名不作为标签值，避免指标基数失控
+		metrics.WebhooksReceived.WithLabelValues("unknown").Inc()
+		h.handleUnknownEvent(w, payload)
+		return
+	}
+	metrics.WebhooksReceived.WithLabelValues(payload.Event).Inc()
+
+	// 基线尚未就绪时先排队，初始化完成后再处理
+	if h.enqueueWebhook(payload) {
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{
+			"status":  "queued",
+			"message": "API 列表初始化中，Webhook 已排队",
+		})
 		return
 	}
 
-	// 标记是否为新创建的 API
-	isNewApi := payload.Event == "API_CREATED"
+	h.dispatchWebhook(r.Context(), w, payload, info)
+}
+
+// dispatchWebhook 按事件类型分发处理
+func (h *ApiNotifyHandler) dispatchWebhook(ctx context.Context, w http.ResponseWriter, payload apifox.WebhookPayload, info apifox.WebhookEventInfo) {
+	switch {
+	case info.Category == apifox.CategoryApi && info.Action == apifox.ActionDeleted:
+		h.handleApiDeleted(w, payload)
+	case info.Category == apifox.CategoryApi:
+		h.handleApiChanged(ctx, w, payload, info.Action == apifox.ActionCreated)
+	default:
+		h.handleResourceEvent(ctx, w, payload, info)
+	}
+}
 
+// handleApiChanged 处理接口创建/修改事件
+func (h *ApiNotifyHandler) handleApiChanged(ctx context.Context, w http.ResponseWriter, payload apifox.WebhookPayload, isNewApi bool) {
 	// 解析 webhook 内容获取 API 名称和路径
 	apiName, apiPath, err := apifox.ParseWebhookContent(payload.Content)
 	if err != nil {
 		h.logger.WithError(err).Error("解析 Webhook 内容失败")
+		metrics.WebhookParseFailures.WithLabelValues("content").Inc()
 		http.Error(w, "解析 Webhook 内容失败", http.StatusBadRequest)
 		return
 	}
@@ -84,6 +120,7 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 	method := apifox.ExtractMethodFromPath(apiPath)
 	if method == "" {
 		h.logger.WithError(fmt.Errorf("无法从路径提取 HTTP 方法: %s", apiPath)).Error("解析 API 路径失败")
+		metrics.WebhookParseFailures.WithLabelValues("path").Inc()
 		http.Error(w, "无法从路径提取 HTTP 方法", http.StatusBadRequest)
 		return
 	}
@@ -100,10 +137,10 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 
 	// 步骤1: 获取最新的API映射信息
 	h.logger.Info("正在获取最新的 API 映射信息以匹配更改")
-	apiMappings, err := h.apifoxClient.GetApiMappings()
+	apiMappings, err := h.apifoxClient.GetApiMappings(ctx)
 	if err != nil {
 		h.logger.WithError(err).Error("获取 API 映射信息失败")
-		http.Error(w, "无法获取最新 API 信息", http.StatusInternalServerError)
+		http.Error(w, "无法获取最新 API 信息", apifoxErrorStatus(err))
 		return
 	}
 
@@ -130,16 +167,20 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 		h.logger.WithField("api_key", oldApiInfo.ApiKey).Info("使用存储的 API 信息处理变更")
 
 		// 获取API详情
-		apiDetailResp, err := h.apifoxClient.GetApiDetail(oldApiInfo.ApiKey)
+		apiDetailResp, err := h.apifoxClient.GetApiDetail(ctx, oldApiInfo.ApiKey)
 		if err != nil {
 			h.logger.WithError(err).Error("获取 API 详情失败")
-			http.Error(w, "无法获取 API 详情", http.StatusInternalServerError)
+			http.Error(w, "无法获取 API 详情", apifoxErrorStatus(err))
 			return
 		}
 
-		// 检查责任人过滤
-		if h.apifoxClient.GetConfig().ResponsibleId != apiDetailResp.Data.ResponsibleID {
+		if oldApiInfo.Incomplete {
+			h.completeBaseline(w, oldApiInfo.ApiKey, apiDetailResp.Data)
+			return
+		}
 
+		// 检查责任人过滤，从配置的负责人交接出去的变更同样通知
+		if !h.isResponsible(apiDetailResp.Data.ResponsibleID) && !h.isResponsible(oldApiInfo.Detail.ResponsibleID) {
 			h.logger.WithFields(logrus.Fields{
 				"api_name":              oldApiInfo.Name,
 				"api_id":                oldApiInfo.ApiID,
@@ -147,7 +188,8 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 				"api_responsible_id":    apiDetailResp.Data.ResponsibleID,
 			}).Info("API负责人与配置的负责人不匹配，跳过通知")
 
-			// 仍然保存API信息，但不发送通知
+			// 仍然保存API信息并记录变更历史，但不发送通知
+			diff := h.diffService.CompareApis(oldApiInfo.Detail, apiDetailResp.Data, modifierName, modifiedTime)
 			apiInfo := apifox.StoredApiInfo{
 				ApiKey:    oldApiInfo.ApiKey,
 				ApiID:     apiDetailResp.Data.ID,
@@ -160,6 +202,8 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 
 			if err := h.apiStore.SaveApi(apiInfo); err != nil {
 				h.logger.WithError(err).WithField("apiKey", oldApiInfo.ApiKey).Error("更新 API 信息失败")
+			} else if diff.HasChanges() {
+				h.apiService.RecordChange(diff, apiDetailResp.Data, apifox.ActionUpdated, apifox.SourceWebhook)
 			}
 
 			w.WriteHeader(http.StatusOK)
@@ -170,7 +214,7 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 		diff := h.diffService.CompareApis(oldApiInfo.Detail, apiDetailResp.Data, modifierName, modifiedTime)
 
 		// 检查是否有差异
-		if diff.PathDiff || diff.MethodDiff || diff.RequestBodyDiff || diff.ParametersDiff || diff.ResponsesDiff {
+		if diff.HasChanges() {
 			// 发送通知
 			if err := h.notifyService.SendApiChangedNotification(*diff); err != nil {
 				h.logger.WithError(err).Error("发送 API 变更通知失败")
@@ -191,6 +235,8 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 
 			if err := h.apiStore.SaveApi(apiInfo); err != nil {
 				h.logger.WithError(err).WithField("apiKey", oldApiInfo.ApiKey).Error("更新 API 信息失败")
+			} else {
+				h.apiService.RecordChange(diff, apiDetailResp.Data, apifox.ActionUpdated, apifox.SourceWebhook)
 			}
 		} else {
 			h.logger.WithField("apiKey", oldApiInfo.ApiKey).Info("API 没有实质性变更，不发送通知")
@@ -218,15 +264,20 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 		}
 
 		// 获取API详情
-		apiDetailResp, err := h.apifoxClient.GetApiDetail(apiKey)
+		apiDetailResp, err := h.apifoxClient.GetApiDetail(ctx, apiKey)
 		if err != nil {
 			h.logger.WithError(err).Error("获取 API 详情失败")
-			http.Error(w, "无法获取 API 详情", http.StatusInternalServerError)
+			http.Error(w, "无法获取 API 详情", apifoxErrorStatus(err))
 			return
 		}
 
-		// 检查责任人过滤
-		if h.apifoxClient.GetConfig().ResponsibleId != apiDetailResp.Data.ResponsibleID {
+		if oldExists && oldApiInfo.Incomplete {
+			h.completeBaseline(w, apiKey, apiDetailResp.Data)
+			return
+		}
+
+		// 检查责任人过滤，从配置的负责人交接出去的变更同样通知
+		if !h.isResponsible(apiDetailResp.Data.ResponsibleID) && !(oldExists && h.isResponsible(oldApiInfo.Detail.ResponsibleID)) {
 			h.logger.WithFields(logrus.Fields{
 				"api_name":              apiBasic.Name,
 				"api_id":                apiBasic.ID,
@@ -234,7 +285,7 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 				"api_responsible_id":    apiDetailResp.Data.ResponsibleID,
 			}).Info("API负责人与配置的负责人不匹配，跳过通知")
 
-			// 仍然保存API信息，但不发送通知
+			// 仍然保存API信息并记录变更历史，但不发送通知
 			apiInfo := apifox.StoredApiInfo{
 				ApiKey:    apiKey,
 				ApiID:     apiBasic.ID,
@@ -247,19 +298,27 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 
 			if err := h.apiStore.SaveApi(apiInfo); err != nil {
 				h.logger.WithError(err).WithField("apiKey", apiKey).Error("更新/保存 API 信息失败")
+			} else if !oldExists {
+				h.apiService.RecordChange(nil, apiDetailResp.Data, apifox.ActionCreated, apifox.SourceWebhook)
+			} else if diff := h.diffService.CompareApis(oldApiInfo.Detail, apiDetailResp.Data, modifierName, modifiedTime); diff.HasChanges() {
+				h.apiService.RecordChange(diff, apiDetailResp.Data, apifox.ActionUpdated, apifox.SourceWebhook)
 			}
 
 			w.WriteHeader(http.StatusOK)
 			return
 		}
 
+		// 需要记录到变更历史的差异，createdDiff 仅在新 API 时使用
+		var diff *apifox.ApiDiff
+		var createdDiff *apifox.ApiDiff
+
 		// 如果找到旧信息，则比较差异
 		if oldExists {
 			// 比较差异
-			diff := h.diffService.CompareApis(oldApiInfo.Detail, apiDetailResp.Data, modifierName, modifiedTime)
+			diff = h.diffService.CompareApis(oldApiInfo.Detail, apiDetailResp.Data, modifierName, modifiedTime)
 
 			// 检查是否有差异
-			if diff.PathDiff || diff.MethodDiff || diff.RequestBodyDiff || diff.ParametersDiff || diff.ResponsesDiff {
+			if diff.HasChanges() {
 				// 发送通知
 				if err := h.notifyService.SendApiChangedNotification(*diff); err != nil {
 					h.logger.WithError(err).Error("发送 API 变更通知失败")
@@ -276,7 +335,7 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 			// 如果是 API_CREATED 事件，发送 API 创建通知
 			if isNewApi {
 				// 创建一个包含新API信息的差异对象
-				createdDiff := &apifox.ApiDiff{
+				createdDiff = &apifox.ApiDiff{
 					ApiID:        apiBasic.ID,
 					Name:         apiBasic.Name,
 					NewPath:      apiBasic.Path,
@@ -310,6 +369,10 @@ func (h *ApiNotifyHandler) HandleWebhook(w http.ResponseWriter, r *http.Request)
 
 		if err := h.apiStore.SaveApi(apiInfo); err != nil {
 			h.logger.WithError(err).WithField("apiKey", apiKey).Error("更新/保存 API 信息失败")
+		} else if !oldExists {
+			h.apiService.RecordChange(createdDiff, apiDetailResp.Data, apifox.ActionCreated, apifox.SourceWebhook)
+		} else if diff.HasChanges() {
+			h.apiService.RecordChange(diff, apiDetailResp.Data, apifox.ActionUpdated, apifox.SourceWebhook)
 		}
 	}
 
@@ -325,3 +388,11 @@ func (h *ApiNotifyHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 		"time":   time.Now().Format(time.RFC3339),
 	})
 }
+
+// apifoxErrorStatus 将 Apifox 请求错误映射为 HTTP 状态码，凭证失效时返回 503 以便调用方稍后重试
+func apifoxErrorStatus(err error) int {
+	if errors.Is(err, apifox.ErrUnauthorized) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
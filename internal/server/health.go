@@ -0,0 +1,164 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// 组件检查结果
+const (
+	componentOK   = "ok"
+	componentFail = "fail"
+)
+
+// ComponentStatus 单个依赖组件的检查结果
+type ComponentStatus struct {
+	Status  string      `json:"status"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// LivenessCheck 存活检查，进程能响应即视为存活
+func (h *ApiNotifyHandler) LivenessCheck(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		"time":   time.Now().Format(time.RFC3339),
+	})
+}
+
+// ReadinessCheck 就绪检查，所有依赖组件正常时返回 200，否则返回 503
+func (h *ApiNotifyHandler) ReadinessCheck(w http.ResponseWriter, r *http.Request) {
+	components := map[string]ComponentStatus{
+		"initialization": h.checkInitialization(),
+		"sync":           h.checkSync(),
+		"apifox_auth":    h.checkApifoxAuth(),
+		"notifier":       h.checkNotifier(),
+	}
+
+	status := "ok"
+	httpStatus := http.StatusOK
+	for _, component := range components {
+		if component.Status != componentOK {
+			status = "unavailable"
+			httpStatus = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	writeJSON(w, httpStatus, map[string]interface{}{
+		"status":     status,
+		"components": components,
+		"time":       time.Now().Format(time.RFC3339),
+	})
+}
+
+// InitProgress 返回启动初始化进度及排队中的 Webhook 数量
+func (h *ApiNotifyHandler) InitProgress(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"progress":         h.apiService.InitProgress(),
+		"pending_webhooks": h.PendingWebhooks(),
+	})
+}
+
+// checkInitialization 检查 API 列表初始化是否完成
+func (h *ApiNotifyHandler) checkInitialization() ComponentStatus {
+	status := h.apiService.Status()
+
+	if progress := h.apiService.InitProgress(); progress.Running {
+		return ComponentStatus{
+			Status:  componentFail,
+			Message: fmt.Sprintf("初始化进行中（%d/%d，失败 %d）", progress.Done+progress.Failed, progress.Total, progress.Failed),
+			Details: progress,
+		}
+	}
+
+	switch {
+	case status.InitError != "":
+		return ComponentStatus{Status: componentFail, Message: "初始化失败: " + status.InitError}
+	case !status.Initialized:
+		return ComponentStatus{Status: componentFail, Message: "初始化尚未完成"}
+	default:
+		return ComponentStatus{
+			Status:  componentOK,
+			Message: "初始化已完成",
+			Details: map[string]interface{}{
+				"finished_at": status.InitFinishedAt.Format(time.RFC3339),
+			},
+		}
+	}
+}
+
+// checkSync 检查最近一次成功同步距今是否超过两个同步周期
+func (h *ApiNotifyHandler) checkSync() ComponentStatus {
+	status := h.apiService.Status()
+	if !status.SyncRunning {
+		return ComponentStatus{Status: componentOK, Message: "定时同步未启用"}
+	}
+
+	// 尚未成功同步过时，从初始化完成时间开始计算
+	last := status.LastSyncAt
+	if last.IsZero() {
+		last = status.InitFinishedAt
+	}
+	maxAge := 2*h.apiService.SyncInterval() + 5*time.Minute
+
+	details := map[string]interface{}{
+		"last_sync_at":         formatTime(status.LastSyncAt),
+		"last_sync_attempt_at": formatTime(status.LastSyncAttemptAt),
+		"last_sync_error":      status.LastSyncError,
+		"max_age":              maxAge.String(),
+	}
+
+	if last.IsZero() {
+		return ComponentStatus{Status: componentFail, Message: "尚未完成过同步", Details: details}
+	}
+
+	age := time.Since(last)
+	details["age"] = age.Round(time.Second).String()
+	if age > maxAge {
+		return ComponentStatus{
+			Status:  componentFail,
+			Message: fmt.Sprintf("距最近一次成功同步已超过 %s", maxAge),
+			Details: details,
+		}
+	}
+
+	return ComponentStatus{Status: componentOK, Message: "同步正常", Details: details}
+}
+
+// checkApifoxAuth 根据最近的 Apifox 响应判断凭证是否有效
+func (h *ApiNotifyHandler) checkApifoxAuth() ComponentStatus {
+	state := h.apifoxClient.AuthState()
+	if !state.Checked {
+		return ComponentStatus{Status: componentOK, Message: "尚未请求过 Apifox", Details: state}
+	}
+	if !state.Valid {
+		return ComponentStatus{
+			Status:  componentFail,
+			Message: fmt.Sprintf("Apifox 凭证失效（HTTP %d）", state.LastStatus),
+			Details: state,
+		}
+	}
+	return ComponentStatus{Status: componentOK, Message: "Apifox 凭证有效", Details: state}
+}
+
+// checkNotifier 检查通知通道是否已配置且最近一次发送成功
+func (h *ApiNotifyHandler) checkNotifier() ComponentStatus {
+	status := h.notifyService.Status()
+	if !status.Configured {
+		return ComponentStatus{Status: componentFail, Message: "未配置钉钉 Webhook", Details: status}
+	}
+	if status.LastError != "" && status.LastErrorAt.After(status.LastSentAt) {
+		return ComponentStatus{Status: componentFail, Message: "最近一次通知发送失败: " + status.LastError, Details: status}
+	}
+	return ComponentStatus{Status: componentOK, Message: "通知通道正常", Details: status}
+}
+
+// formatTime 格式化时间，零值返回空字符串
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
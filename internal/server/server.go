@@ -9,19 +9,23 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/internal/metrics"
 )
 
 // Server HTTP 服务器
 type Server struct {
-	router  *chi.Mux
-	port    int
-	logger  *logrus.Logger
-	handler *ApiNotifyHandler
-	srv     *http.Server
+	router        *chi.Mux
+	port          int
+	logger        *logrus.Logger
+	handler       *ApiNotifyHandler
+	queryHandler  *ApiQueryHandler
+	adminHandler  *AdminHandler
+	digestHandler *DigestHandler
+	srv           *http.Server
 }
 
 // NewServer 创建新的 HTTP 服务器
-func NewServer(port int, handler *ApiNotifyHandler, logger *logrus.Logger) *Server {
+func NewServer(port int, handler *ApiNotifyHandler, queryHandler *ApiQueryHandler, adminHandler *AdminHandler, digestHandler *DigestHandler, logger *logrus.Logger) *Server {
 	r := chi.NewRouter()
 
 	// 添加中间件
@@ -32,29 +36,79 @@ func NewServer(port int, handler *ApiNotifyHandler, logger *logrus.Logger) *Serv
 	r.Use(middleware.Timeout(60 * time.Second))
 
 	return &Server{
-		router:  r,
-		port:    port,
-		logger:  logger,
-		handler: handler,
+		router:        r,
+		port:          port,
+		logger:        logger,
+		handler:       handler,
+		queryHandler:  queryHandler,
+		adminHandler:  adminHandler,
+		digestHandler: digestHandler,
 	}
 }
 
 // SetupRoutes 设置路由
 func (s *Server) SetupRoutes() {
 	s.router.Get("/health", s.handler.HealthCheck)
+	s.router.Get("/health/live", s.handler.LivenessCheck)
+	s.router.Get("/health/ready", s.handler.ReadinessCheck)
+	s.router.Get("/init/progress", s.handler.InitProgress)
+	s.router.Handle("/metrics", metrics.Handler())
 	s.router.Post("/webhook", s.handler.HandleWebhook)
+	s.router.Get("/webhook/unknown-events", s.handler.GetUnknownEvents)
+
+	// 只读 API 目录
+	s.router.Get("/apis", s.queryHandler.ListApis)
+	s.router.Get("/apis/match", s.queryHandler.MatchApi)
+	s.router.Get("/apis/{key}", s.queryHandler.GetApi)
+	s.router.Get("/apis/{key}/versions", s.queryHandler.GetApiVersions)
+	s.router.Get("/apis/{key}/diff", s.queryHandler.DiffApiVersions)
+	s.router.Post("/diff", s.queryHandler.DiffApiDetails)
+
+	// 变更历史与看板
+	s.router.Get("/changes", s.queryHandler.ListChanges)
+	s.router.Get("/dashboard", s.queryHandler.Dashboard)
+	s.router.Get("/digest/preview", s.digestHandler.Preview)
+	s.router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/dashboard", http.StatusFound)
+	})
+
+	// 管理接口，未配置 ADMIN_TOKEN 时不注册
+	if !s.adminHandler.Enabled() {
+		s.logger.Warn("未配置 ADMIN_TOKEN，管理接口已禁用")
+		return
+	}
+	s.router.Route("/admin", func(r chi.Router) {
+		r.Use(s.adminHandler.Authenticate)
+		r.Post("/reinit", s.adminHandler.Reinit)
+		r.Post("/sync", s.adminHandler.SyncAll)
+		r.Post("/sync/{key}", s.adminHandler.SyncApi)
+		r.Post("/sync-loop/start", s.adminHandler.StartSyncLoop)
+		r.Post("/sync-loop/stop", s.adminHandler.StopSyncLoop)
+		r.Post("/store/clear", s.adminHandler.ClearStore)
+		r.Post("/store/rebuild", s.adminHandler.RebuildStore)
+		r.Put("/apifox/token", s.adminHandler.UpdateApifoxToken)
+		r.Get("/retries", s.adminHandler.ListRetries)
+		r.Get("/jobs", s.adminHandler.ListJobs)
+		r.Get("/jobs/{id}", s.adminHandler.GetJob)
+	})
+}
+
+// Handler 注册路由并返回路由器，测试中可直接交给 httptest 启动
+func (s *Server) Handler() http.Handler {
+	s.SetupRoutes()
+	return s.router
 }
 
 // Start 启动服务器
 func (s *Server) Start() error {
-	s.SetupRoutes()
+	handler := s.Handler()
 
 	addr := fmt.Sprintf(":%d", s.port)
 	s.logger.WithField("port", s.port).Info("启动 HTTP 服务器")
 
 	s.srv = &http.Server{
 		Addr:    addr,
-		Handler: s.router,
+		Handler: handler,
 	}
 
 	return s.srv.ListenAndServe()
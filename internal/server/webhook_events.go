@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/internal/apifox"
+	"github.com/xhy/api-pulse/internal/dingtalk"
+	"github.com/xhy/api-pulse/internal/metrics"
+)
+
+// handleApiDeleted 处理接口删除事件
+func (h *ApiNotifyHandler) handleApiDeleted(w http.ResponseWriter, payload apifox.WebhookPayload) {
+	apiName, apiPath, err := apifox.ParseWebhookContent(payload.Content)
+	if err != nil {
+		h.logger.WithError(err).Error("解析 Webhook 内容失败")
+		metrics.WebhookParseFailures.WithLabelValues("content").Inc()
+		http.Error(w, "解析 Webhook 内容失败", http.StatusBadRequest)
+		return
+	}
+
+	modifierName, modifiedTime := dingtalk.ExtractNameTimeFromContent(payload.Content)
+
+	// 接口在 Apifox 中已不存在，只能依赖本地存储的快照
+	oldApiInfo, exists := h.findStoredApi(apiName, apiPath)
+	if !exists {
+		h.logger.WithFields(logrus.Fields{
+			"api_name": apiName,
+			"api_path": apiPath,
+		}).Warn("未找到被删除 API 的存储信息，忽略删除事件")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	deletedDiff := apifox.ApiDiff{
+		ApiKey:       oldApiInfo.ApiKey,
+		ApiID:        oldApiInfo.ApiID,
+		Name:         oldApiInfo.Name,
+		OldMethod:    oldApiInfo.Method,
+		OldPath:      oldApiInfo.ApiPath,
+		ModifierName: modifierName,
+		ModifiedTime: modifiedTime,
+		IsDeleted:    true,
+	}
+
+	h.apiService.RecordChange(&deletedDiff, oldApiInfo.Detail, apifox.ActionDeleted, apifox.SourceWebhook)
+	h.apiStore.DeleteApi(oldApiInfo.ApiKey)
+	h.apiService.CancelRetry(oldApiInfo.ApiKey)
+
+	if !h.isResponsible(oldApiInfo.Detail.ResponsibleID) {
+		h.logger.WithFields(logrus.Fields{
+			"api_name":           oldApiInfo.Name,
+			"api_responsible_id": oldApiInfo.Detail.ResponsibleID,
+		}).Info("API负责人与配置的负责人不匹配，跳过删除通知")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := h.notifyService.SendApiDeletedNotification(deletedDiff); err != nil {
+		h.logger.WithError(err).Error("发送 API 删除通知失败")
+		http.Error(w, "发送通知失败", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleResourceEvent 处理文档、数据模型、目录、测试用例、分支合并等非接口事件
+func (h *ApiNotifyHandler) handleResourceEvent(ctx context.Context, w http.ResponseWriter, payload apifox.WebhookPayload, info apifox.WebhookEventInfo) {
+	fields := apifox.ParseWebhookFields(payload.Content)
+	modifierName, modifiedTime := dingtalk.ExtractNameTimeFromContent(payload.Content)
+
+	event := apifox.ResourceEvent{
+		Event:        payload.Event,
+		Category:     info.Category,
+		Action:       info.Action,
+		Title:        payload.Title,
+		Name:         apifox.ResourceName(fields, payload.Title),
+		ModifierName: modifierName,
+		ModifiedTime: modifiedTime,
+		Fields:       fields,
+	}
+
+	// 测试用例等事件可能关联到具体接口，此时沿用接口的负责人过滤
+	if apiPath := fields["接口路径"]; apiPath != "" {
+		if apiInfo, exists := h.findStoredApi(fields["接口名称"], apiPath); exists && !h.isResponsible(apiInfo.Detail.ResponsibleID) {
+			h.logger.WithFields(logrus.Fields{
+				"event":    payload.Event,
+				"api_name": apiInfo.Name,
+			}).Info("关联 API 的负责人与配置的负责人不匹配，跳过通知")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	// 分支合并后接口可能批量变化，异步刷新一次本地快照
+	if info.Category == apifox.CategoryBranch {
+		h.logger.WithField("event", payload.Event).Info("检测到分支合并，触发全量同步")
+		// 同步在响应后继续执行，不随请求取消
+		go h.apiService.FullSyncAllAPIs(context.WithoutCancel(ctx), nil)
+	}
+
+	if err := h.notifyService.SendResourceNotification(event); err != nil {
+		h.logger.WithError(err).WithField("event", payload.Event).Error("发送资源变更通知失败")
+		http.Error(w, "发送通知失败", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleUnknownEvent 保存无法识别的事件，便于后续排查和补充支持
+func (h *ApiNotifyHandler) handleUnknownEvent(w http.ResponseWriter, payload apifox.WebhookPayload) {
+	h.logger.WithFields(logrus.Fields{
+		"event": payload.Event,
+		"title": payload.Title,
+	}).Warn("收到未知的 Webhook 事件，已保存待排查")
+
+	h.apiStore.SaveUnknownEvent(apifox.WebhookEventRecord{
+		Event:      payload.Event,
+		Title:      payload.Title,
+		Content:    payload.Content,
+		ReceivedAt: time.Now().Format("2006-01-02 15:04:05"),
+	})
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetUnknownEvents 返回保存的未知 Webhook 事件
+func (h *ApiNotifyHandler) GetUnknownEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"events": h.apiStore.GetUnknownEvents(),
+	})
+}
+
+// completeBaseline 旧基线不完整时只保存最新详情作为基线，不比较也不通知
+func (h *ApiNotifyHandler) completeBaseline(w http.ResponseWriter, apiKey string, detail apifox.ApiDetail) {
+	if detail.ID == 0 {
+		h.logger.WithField("apiKey", apiKey).Warn("API 详情无效，基线仍不完整，等待重试")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	apiInfo := apifox.StoredApiInfo{
+		ApiKey:    apiKey,
+		ApiID:     detail.ID,
+		Name:      detail.Name,
+		Method:    strings.ToLower(detail.Method),
+		ApiPath:   detail.Path,
+		Detail:    detail,
+		UpdatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := h.apiStore.SaveApi(apiInfo); err != nil {
+		h.logger.WithError(err).WithField("apiKey", apiKey).Error("更新 API 信息失败")
+		http.Error(w, "保存 API 信息失败", http.StatusInternalServerError)
+		return
+	}
+	h.apiService.CancelRetry(apiKey)
+
+	h.logger.WithField("apiKey", apiKey).Info("API 基线不完整，已补全基线，本次不比较差异")
+	w.WriteHeader(http.StatusOK)
+}
+
+// findStoredApi 根据 webhook 中的接口路径（如 "POST /users"）或名称查找存储的 API
+func (h *ApiNotifyHandler) findStoredApi(apiName, apiPath string) (apifox.StoredApiInfo, bool) {
+	method, path := splitMethodPath(apiPath)
+	if method != "" && path != "" {
+		if apiInfo, exists := h.apiStore.GetApiByPath(method, path); exists {
+			return apiInfo, true
+		}
+	}
+
+	if apiName == "" {
+		return apifox.StoredApiInfo{}, false
+	}
+	for _, apiInfo := range h.apiStore.GetAllApis() {
+		if apiInfo.Name == apiName {
+			return apiInfo, true
+		}
+	}
+	return apifox.StoredApiInfo{}, false
+}
+
+// isResponsible 检查 API 负责人是否与配置的负责人一致
+func (h *ApiNotifyHandler) isResponsible(responsibleID int) bool {
+	return h.apifoxClient.GetConfig().ResponsibleId == responsibleID
+}
+
+// splitMethodPath 将 "POST /users/{id}" 拆分为小写方法和路径
+func splitMethodPath(apiPath string) (string, string) {
+	parts := strings.Fields(apiPath)
+	if len(parts) < 2 {
+		return "", ""
+	}
+	return strings.ToLower(parts[0]), parts[1]
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/internal/apifox"
+	"github.com/xhy/api-pulse/internal/metrics"
+)
+
+// maxPendingWebhooks 基线就绪前最多排队的 Webhook 数量，超出时丢弃最早的
+const maxPendingWebhooks = 1000
+
+// enqueueWebhook 基线未就绪时将 Webhook 加入队列，返回是否已排队
+func (h *ApiNotifyHandler) enqueueWebhook(payload apifox.WebhookPayload) bool {
+	h.queueMutex.Lock()
+	defer h.queueMutex.Unlock()
+
+	if h.baselineReady {
+		return false
+	}
+
+	if len(h.pending) >= maxPendingWebhooks {
+		h.logger.WithField("event", h.pending[0].Event).Warn("Webhook 队列已满，丢弃最早的 Webhook")
+		h.pending = h.pending[1:]
+	}
+	h.pending = append(h.pending, payload)
+	metrics.WebhookQueueSize.Set(float64(len(h.pending)))
+
+	h.logger.WithFields(logrus.Fields{
+		"event":   payload.Event,
+		"pending": len(h.pending),
+	}).Info("API 列表尚未初始化完成，Webhook 已排队")
+	return true
+}
+
+// PendingWebhooks 返回排队中的 Webhook 数量
+func (h *ApiNotifyHandler) PendingWebhooks() int {
+	h.queueMutex.Lock()
+	defer h.queueMutex.Unlock()
+
+	return len(h.pending)
+}
+
+// MarkBaselineReady 标记基线已就绪，按接收顺序处理排队的 Webhook，之后的 Webhook 直接处理
+func (h *ApiNotifyHandler) MarkBaselineReady(ctx context.Context) {
+	processed := 0
+	for {
+		h.queueMutex.Lock()
+		if len(h.pending) == 0 {
+			// 队列处理完毕后才放行新的 Webhook，保证处理顺序
+			h.baselineReady = true
+			metrics.WebhookQueueSize.Set(0)
+			h.queueMutex.Unlock()
+			break
+		}
+		batch := h.pending
+		h.pending = nil
+		metrics.WebhookQueueSize.Set(0)
+		h.queueMutex.Unlock()
+
+		for _, payload := range batch {
+			h.replayWebhook(ctx, payload)
+		}
+		processed += len(batch)
+	}
+
+	h.logger.WithField("processed", processed).Info("基线已就绪，排队的 Webhook 已处理")
+}
+
+// replayWebhook 处理一条排队的 Webhook
+func (h *ApiNotifyHandler) replayWebhook(ctx context.Context, payload apifox.WebhookPayload) {
+	info, _ := apifox.ClassifyWebhookEvent(payload.Event)
+
+	w := &discardResponseWriter{header: make(http.Header), status: http.StatusOK}
+	h.dispatchWebhook(ctx, w, payload, info)
+
+	entry := h.logger.WithFields(logrus.Fields{
+		"event":  payload.Event,
+		"title":  payload.Title,
+		"status": w.status,
+	})
+	if w.status >= http.StatusBadRequest {
+		entry.Warn("排队的 Webhook 处理失败")
+	} else {
+		entry.Info("排队的 Webhook 已处理")
+	}
+}
+
+// discardResponseWriter 丢弃响应内容，仅记录状态码，用于处理排队的 Webhook
+type discardResponseWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -8,31 +9,81 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/xhy/api-pulse/internal/apifox"
+	"github.com/xhy/api-pulse/internal/metrics"
 	"github.com/xhy/api-pulse/internal/storage"
 )
 
+// 单个 API 的同步结果
+const (
+	SyncOutcomeUpdated   = "updated"
+	SyncOutcomeUnchanged = "unchanged"
+	SyncOutcomeNew       = "new"
+	SyncOutcomeBaselined = "baselined" // 补全了不完整的基线
+	SyncOutcomeSkipped   = "skipped"   // 增量同步中树形列表元数据未变化，未获取详情
+	SyncOutcomeError     = "error"
+)
+
+// defaultFullSweepInterval 默认的全量同步间隔
+const defaultFullSweepInterval = 6 * time.Hour
+
 // ApiService API服务
 type ApiService struct {
 	logger        *logrus.Logger
-	apifox        *apifox.Client
+	apifox        apifox.Source
 	storage       *storage.ApiStore
 	diffService   *apifox.DiffService
 	syncInterval  time.Duration
-	stopSync      chan struct{}
+	fullSweep     time.Duration // 全量同步间隔，其余同步只获取元数据有变化的 API
+	cancelSync    context.CancelFunc
+	syncDone      chan struct{}
 	isSyncRunning bool
 	syncMutex     sync.Mutex
+	workMutex     sync.Mutex // 串行执行全量初始化与同步
+	retries       *retryQueue
+
+	statusMutex   sync.RWMutex
+	status        Status
+	initRunning   bool
+	initStartedAt time.Time
+	initProgress  *Progress
+}
+
+// Status API 服务的运行状态
+type Status struct {
+	Initialized       bool      `json:"initialized"`
+	InitError         string    `json:"init_error,omitempty"`
+	InitFinishedAt    time.Time `json:"init_finished_at"`
+	SyncRunning       bool      `json:"sync_running"`
+	SyncInterval      string    `json:"sync_interval"`
+	LastSyncAt        time.Time `json:"last_sync_at"`
+	LastSyncAttemptAt time.Time `json:"last_sync_attempt_at"`
+	LastSyncError     string    `json:"last_sync_error,omitempty"`
+	LastFullSweepAt   time.Time `json:"last_full_sweep_at"`
+}
+
+// InitProgress API 列表初始化进度
+type InitProgress struct {
+	Running    bool      `json:"running"`
+	Finished   bool      `json:"finished"`
+	Total      int64     `json:"total"`
+	Done       int64     `json:"done"`
+	Failed     int64     `json:"failed"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Error      string    `json:"error,omitempty"`
 }
 
 // NewApiService 创建新的API服务
-func NewApiService(logger *logrus.Logger, client *apifox.Client, storage *storage.ApiStore, diffService *apifox.DiffService) *ApiService {
+func NewApiService(logger *logrus.Logger, client apifox.Source, storage *storage.ApiStore, diffService *apifox.DiffService) *ApiService {
 	return &ApiService{
 		logger:        logger,
 		apifox:        client,
 		storage:       storage,
 		diffService:   diffService,
 		syncInterval:  time.Hour, // 默认1小时同步一次
-		stopSync:      make(chan struct{}),
+		fullSweep:     defaultFullSweepInterval,
 		isSyncRunning: false,
+		retries:       newRetryQueue(),
 	}
 }
 
@@ -41,8 +92,117 @@ func (s *ApiService) SetSyncInterval(interval time.Duration) {
 	s.syncInterval = interval
 }
 
-// StartSync 开始周期性同步
-func (s *ApiService) StartSync() {
+// SetFullSweepInterval 设置全量同步间隔
+func (s *ApiService) SetFullSweepInterval(interval time.Duration) {
+	s.fullSweep = interval
+}
+
+// Status 返回服务当前的运行状态
+func (s *ApiService) Status() Status {
+	s.statusMutex.RLock()
+	status := s.status
+	s.statusMutex.RUnlock()
+
+	s.syncMutex.Lock()
+	status.SyncRunning = s.isSyncRunning
+	s.syncMutex.Unlock()
+	status.SyncInterval = s.syncInterval.String()
+
+	return status
+}
+
+// SyncInterval 返回同步间隔
+func (s *ApiService) SyncInterval() time.Duration {
+	return s.syncInterval
+}
+
+// InitProgress 返回最近一次初始化的进度
+func (s *ApiService) InitProgress() InitProgress {
+	s.statusMutex.RLock()
+	defer s.statusMutex.RUnlock()
+
+	progress := InitProgress{
+		Running:    s.initRunning,
+		Finished:   !s.status.InitFinishedAt.IsZero() && !s.initRunning,
+		StartedAt:  s.initStartedAt,
+		FinishedAt: s.status.InitFinishedAt,
+		Error:      s.status.InitError,
+	}
+	progress.Total, progress.Done, progress.Failed = s.initProgress.Counts()
+	return progress
+}
+
+// beginInit 记录初始化开始
+func (s *ApiService) beginInit(progress *Progress) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	s.initRunning = true
+	s.initStartedAt = time.Now()
+	s.initProgress = progress
+}
+
+// logInitProgress 每完成约 10% 输出一次初始化进度日志
+func (s *ApiService) logInitProgress(progress *Progress) {
+	total, done, failed := progress.Counts()
+	step := total / 10
+	if step == 0 {
+		step = 1
+	}
+	if processed := done + failed; processed%step == 0 || processed == total {
+		s.logger.WithFields(logrus.Fields{
+			"total":  total,
+			"done":   done,
+			"failed": failed,
+		}).Info("API 列表初始化进度")
+	}
+}
+
+// setInitResult 记录初始化结果
+func (s *ApiService) setInitResult(err error) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	s.initRunning = false
+
+	s.status.Initialized = err == nil
+	s.status.InitError = ""
+	if err != nil {
+		s.status.InitError = err.Error()
+	} else {
+		// 初始化获取了全部详情，相当于一次全量同步
+		s.status.LastFullSweepAt = s.initStartedAt
+	}
+	s.status.InitFinishedAt = time.Now()
+}
+
+// setSyncResult 记录同步结果，attemptAt 为本次同步开始时间，full 表示是否为全量同步
+func (s *ApiService) setSyncResult(attemptAt time.Time, full bool, err error) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	s.status.LastSyncAttemptAt = attemptAt
+	if err != nil {
+		s.status.LastSyncError = err.Error()
+		return
+	}
+	s.status.LastSyncError = ""
+	s.status.LastSyncAt = time.Now()
+	if full {
+		s.status.LastFullSweepAt = attemptAt
+	}
+}
+
+// fullSweepDue 距上次成功的全量同步是否已超过全量同步间隔
+func (s *ApiService) fullSweepDue() bool {
+	s.statusMutex.RLock()
+	defer s.statusMutex.RUnlock()
+
+	return s.status.LastFullSweepAt.IsZero() || time.Since(s.status.LastFullSweepAt) >= s.fullSweep
+}
+
+// StartSync 开始周期性同步，ctx 取消或调用 StopSync 时停止
+func (s *ApiService) StartSync(ctx context.Context) {
 	s.syncMutex.Lock()
 	defer s.syncMutex.Unlock()
 
@@ -51,21 +211,30 @@ func (s *ApiService) StartSync() {
 		return
 	}
 
+	loopCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
 	s.isSyncRunning = true
-	s.stopSync = make(chan struct{})
+	s.cancelSync = cancel
+	s.syncDone = done
 
 	go func() {
+		defer close(done)
+
 		ticker := time.NewTicker(s.syncInterval)
 		defer ticker.Stop()
+		retryTicker := time.NewTicker(retryCheckInterval)
+		defer retryTicker.Stop()
 
 		// 立即执行一次同步
-		s.SyncAllAPIs()
+		s.SyncAllAPIs(loopCtx, nil)
 
 		for {
 			select {
 			case <-ticker.C:
-				s.SyncAllAPIs()
-			case <-s.stopSync:
+				s.SyncAllAPIs(loopCtx, nil)
+			case <-retryTicker.C:
+				s.processRetries(loopCtx)
+			case <-loopCtx.Done():
 				s.logger.Info("停止API同步任务")
 				return
 			}
@@ -75,53 +244,79 @@ func (s *ApiService) StartSync() {
 	s.logger.WithField("interval", s.syncInterval.String()).Info("已启动API定时同步")
 }
 
-// StopSync 停止周期性同步
+// StopSync 停止周期性同步，取消进行中的同步并等待其退出
 func (s *ApiService) StopSync() {
 	s.syncMutex.Lock()
-	defer s.syncMutex.Unlock()
-
 	if !s.isSyncRunning {
+		s.syncMutex.Unlock()
 		return
 	}
 
-	close(s.stopSync)
+	s.cancelSync()
 	s.isSyncRunning = false
+	done := s.syncDone
+	s.syncMutex.Unlock()
+
+	<-done
 	s.logger.Info("API同步任务已停止")
 }
 
-// SyncAllAPIs 同步所有API信息
-func (s *ApiService) SyncAllAPIs() {
-	s.logger.Info("开始同步所有API信息")
+// SyncAllAPIs 同步所有API信息，progress 可为 nil，ctx 取消时中止并返回 ctx 的错误。
+// 通常只获取树形列表元数据有变化的 API，距上次全量同步超过全量同步间隔时获取全部 API
+func (s *ApiService) SyncAllAPIs(ctx context.Context, progress *Progress) error {
+	return s.syncAll(ctx, progress, s.fullSweepDue())
+}
+
+// FullSyncAllAPIs 获取全部 API 详情进行同步，不依赖树形列表元数据
+func (s *ApiService) FullSyncAllAPIs(ctx context.Context, progress *Progress) error {
+	return s.syncAll(ctx, progress, true)
+}
+
+// syncAll 同步所有API信息，full 为 false 时跳过元数据未变化的 API
+func (s *ApiService) syncAll(ctx context.Context, progress *Progress, full bool) error {
+	s.workMutex.Lock()
+	defer s.workMutex.Unlock()
+
+	s.logger.WithField("full", full).Info("开始同步所有API信息")
+
+	startTime := time.Now()
+	defer func() {
+		metrics.SyncDuration.Observe(time.Since(startTime).Seconds())
+	}()
 
-	// 获取API树形列表
-	resp, err := s.apifox.GetApiTreeList()
+	// 获取API树形列表，Open API 模式下本次同步获取详情时复用这次导出的结果
+	ctx = apifox.WithExportReuse(ctx)
+	resp, err := s.apifox.GetApiTreeList(ctx)
+	if ctx.Err() != nil {
+		s.logger.Info("同步已取消")
+		return ctx.Err()
+	}
 	if err != nil {
 		s.logger.WithError(err).Error("获取API树形列表失败")
-		return
+		s.setSyncResult(startTime, full, err)
+		return err
 	}
 
 	if resp == nil || !resp.Success {
 		s.logger.Warn("API树形列表返回非成功状态")
-		return
+		err := fmt.Errorf("API树形列表请求未成功")
+		s.setSyncResult(startTime, full, err)
+		return err
 	}
 
-	// 提取所有API项
-	apiItems := s.ExtractApiItems(resp.Data)
-	s.logger.WithField("api_count", len(apiItems)).Info("同步：已提取API项")
-
-	// 过滤出类型为apiDetail的项
-	var validApiItems []ApiItem
-	for _, item := range apiItems {
-		if item.Type == "apiDetail" && item.Key != "" {
-			validApiItems = append(validApiItems, item)
-		}
-	}
+	// 提取所有API项，包括各级目录下的
+	validApiItems := ExtractApiItems(&resp.Data)
 	s.logger.WithField("valid_api_count", len(validApiItems)).Info("同步：有效API数量")
 
 	// 获取当前存储的API信息
 	currentApis := s.storage.GetAllApis()
 	s.logger.WithField("current_count", len(currentApis)).Info("当前缓存的API数量")
 
+	// 存储为空时没有可比较的元数据，只能全量获取
+	if len(currentApis) == 0 {
+		full = true
+	}
+
 	// 使用WaitGroup等待所有同步完成
 	var wg sync.WaitGroup
 	wg.Add(len(validApiItems))
@@ -130,92 +325,58 @@ func (s *ApiService) SyncAllAPIs() {
 	maxConcurrency := 5
 	sem := make(chan struct{}, maxConcurrency)
 
+	progress.SetTotal(len(validApiItems))
+
 	// 统计
 	var mutex sync.Mutex
 	updatedCount := 0
 	unchangedCount := 0
 	newCount := 0
+	baselinedCount := 0
+	skippedCount := 0
 	errorCount := 0
 
+	// 存储为空时（如初始化失败）不把所有 API 都记为新增
+	recordNew := len(currentApis) > 0
+
 	// 并发处理每个API项
 	for _, item := range validApiItems {
+		oldApiInfo, exists := currentApis[item.Key]
+		if !full && s.unchangedSinceLastSync(item, oldApiInfo, exists) {
+			wg.Done()
+			progress.Add(true)
+			skippedCount++
+			continue
+		}
+
 		go func(item ApiItem) {
 			defer wg.Done()
 
-			// 占用并发槽
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
-			apiKey := item.Key
-			s.logger.WithField("api_key", apiKey).Debug("同步处理API")
-
-			// 获取API详情
-			apiDetailResp, err := s.apifox.GetApiDetail(apiKey)
-			if err != nil {
-				s.logger.WithError(err).WithField("api_key", apiKey).Error("获取API详情失败")
-				mutex.Lock()
-				errorCount++
-				mutex.Unlock()
-				return
-			}
-
-			if !apiDetailResp.Success || isEmptyApiDetail(apiDetailResp.Data) {
-				s.logger.WithField("api_key", apiKey).Warn("API详情无效")
-				mutex.Lock()
-				errorCount++
-				mutex.Unlock()
+			// 占用并发槽，已取消时不再发起请求
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
 				return
 			}
+			defer func() { <-sem }()
 
-			// 查找存储中是否已有此API
-			oldApiInfo, exists := currentApis[apiKey]
-
-			// 准备新的API信息
-			newApiInfo := apifox.StoredApiInfo{
-				ApiKey:    apiKey,
-				ApiID:     apiDetailResp.Data.ID,
-				Name:      apiDetailResp.Data.Name,
-				Method:    strings.ToLower(apiDetailResp.Data.Method),
-				ApiPath:   apiDetailResp.Data.Path,
-				Detail:    apiDetailResp.Data,
-				UpdatedAt: time.Now().Format("2006-01-02 15:04:05"),
-			}
-
-			if exists {
-				// 比较差异
-				diff := s.diffService.CompareApis(oldApiInfo.Detail, apiDetailResp.Data, "", "")
-
-				// 检查是否有实质性变更
-				if diff.PathDiff || diff.MethodDiff || diff.RequestBodyDiff || diff.ParametersDiff || diff.ResponsesDiff {
-					s.logger.WithFields(logrus.Fields{
-						"api_key":     apiKey,
-						"api_name":    newApiInfo.Name,
-						"path_diff":   diff.PathDiff,
-						"method_diff": diff.MethodDiff,
-						"body_diff":   diff.RequestBodyDiff,
-						"params_diff": diff.ParametersDiff,
-						"resp_diff":   diff.ResponsesDiff,
-					}).Info("检测到API变更")
-
-					mutex.Lock()
-					updatedCount++
-					mutex.Unlock()
-				} else {
-					mutex.Lock()
-					unchangedCount++
-					mutex.Unlock()
-				}
-			} else {
-				// 这是一个新API
-				s.logger.WithField("api_name", newApiInfo.Name).Info("发现新API")
-				mutex.Lock()
+			oldApiInfo, exists := currentApis[item.Key]
+			outcome, _ := s.syncApi(ctx, item.Key, item.Fingerprint, oldApiInfo, exists, recordNew)
+			progress.Add(outcome != SyncOutcomeError)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch outcome {
+			case SyncOutcomeUpdated:
+				updatedCount++
+			case SyncOutcomeUnchanged:
+				unchangedCount++
+			case SyncOutcomeNew:
 				newCount++
-				mutex.Unlock()
-			}
-
-			// 无论是否有变更，都保存最新信息
-			if err := s.storage.SaveApi(newApiInfo); err != nil {
-				s.logger.WithError(err).WithField("api_key", apiKey).Error("保存API信息失败")
+			case SyncOutcomeBaselined:
+				baselinedCount++
+			default:
+				errorCount++
 			}
 		}(item)
 	}
@@ -223,42 +384,177 @@ func (s *ApiService) SyncAllAPIs() {
 	// 等待所有goroutine完成
 	wg.Wait()
 
+	if ctx.Err() != nil {
+		s.logger.Info("同步已取消")
+		return ctx.Err()
+	}
+
+	metrics.SyncApis.WithLabelValues(SyncOutcomeUpdated).Add(float64(updatedCount))
+	metrics.SyncApis.WithLabelValues(SyncOutcomeUnchanged).Add(float64(unchangedCount))
+	metrics.SyncApis.WithLabelValues(SyncOutcomeNew).Add(float64(newCount))
+	metrics.SyncApis.WithLabelValues(SyncOutcomeBaselined).Add(float64(baselinedCount))
+	metrics.SyncApis.WithLabelValues(SyncOutcomeSkipped).Add(float64(skippedCount))
+	metrics.SyncApis.WithLabelValues(SyncOutcomeError).Add(float64(errorCount))
+
+	// 所有需要获取的 API 都获取失败时视为本次同步失败
+	var syncErr error
+	if errorCount > 0 && errorCount == len(validApiItems)-skippedCount {
+		syncErr = fmt.Errorf("全部 %d 个 API 详情获取失败", errorCount)
+	}
+	s.setSyncResult(startTime, full, syncErr)
+
 	s.logger.WithFields(logrus.Fields{
+		"full":      full,
 		"total":     len(validApiItems),
 		"updated":   updatedCount,
 		"unchanged": unchangedCount,
 		"new":       newCount,
+		"baselined": baselinedCount,
+		"skipped":   skippedCount,
 		"error":     errorCount,
 	}).Info("API同步完成")
+
+	return syncErr
+}
+
+// SyncApi 同步单个 API，返回同步结果
+func (s *ApiService) SyncApi(ctx context.Context, apiKey string) (string, error) {
+	oldApiInfo, exists := s.storage.GetApi(apiKey)
+	return s.syncApi(ctx, apiKey, "", oldApiInfo, exists, true)
+}
+
+// unchangedSinceLastSync 树形列表元数据与上次获取详情时一致，且基线完整、不在重试队列中
+func (s *ApiService) unchangedSinceLastSync(item ApiItem, oldApiInfo apifox.StoredApiInfo, exists bool) bool {
+	return exists &&
+		!oldApiInfo.Incomplete &&
+		item.Fingerprint != "" &&
+		item.Fingerprint == oldApiInfo.TreeFingerprint &&
+		!s.retries.has(item.Key)
+}
+
+// syncApi 获取单个 API 的最新详情，与旧快照比较后保存，
+// fingerprint 为树形列表元数据指纹，未知时传空字符串；
+// recordNew 为 false 时新发现的 API 不记入变更历史。
+// 获取失败的 API 会加入重试队列，基线不完整的 API 只补全基线、不做比较
+func (s *ApiService) syncApi(ctx context.Context, apiKey, fingerprint string, oldApiInfo apifox.StoredApiInfo, exists bool, recordNew bool) (string, error) {
+	s.logger.WithField("api_key", apiKey).Debug("同步处理API")
+
+	// 获取API详情
+	apiDetailResp, err := s.apifox.GetApiDetail(ctx, apiKey)
+	if ctx.Err() != nil {
+		// 主动取消不是获取失败，不加入重试队列
+		return SyncOutcomeError, ctx.Err()
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("api_key", apiKey).Error("获取API详情失败")
+		s.scheduleRetry(apiKey, oldApiInfo.Name, err)
+		return SyncOutcomeError, err
+	}
+
+	if !apiDetailResp.Success || isEmptyApiDetail(apiDetailResp.Data) {
+		s.logger.WithField("api_key", apiKey).Warn("API详情无效")
+		err := fmt.Errorf("API详情无效: %s", apiKey)
+		s.scheduleRetry(apiKey, oldApiInfo.Name, err)
+		return SyncOutcomeError, err
+	}
+	if s.retries.remove(apiKey) {
+		s.logger.WithField("api_key", apiKey).Info("API 详情重新获取成功，已移出重试队列")
+	}
+
+	// 准备新的API信息
+	newApiInfo := apifox.StoredApiInfo{
+		ApiKey:          apiKey,
+		ApiID:           apiDetailResp.Data.ID,
+		Name:            apiDetailResp.Data.Name,
+		Method:          strings.ToLower(apiDetailResp.Data.Method),
+		ApiPath:         apiDetailResp.Data.Path,
+		Detail:          apiDetailResp.Data,
+		UpdatedAt:       time.Now().Format("2006-01-02 15:04:05"),
+		TreeFingerprint: fingerprint,
+	}
+
+	// 需要记录到变更历史的变更类型，为空表示无需记录
+	changeType := ""
+	var diff *apifox.ApiDiff
+	outcome := SyncOutcomeUnchanged
+
+	if exists && oldApiInfo.Incomplete {
+		// 旧基线不完整，与其比较只会得到虚假的差异
+		s.logger.WithField("api_key", apiKey).Info("已补全 API 基线")
+		outcome = SyncOutcomeBaselined
+	} else if exists {
+		// 比较差异
+		diff = s.diffService.CompareApis(oldApiInfo.Detail, apiDetailResp.Data, "", "")
+
+		// 检查是否有实质性变更
+		if diff.HasChanges() {
+			s.logger.WithFields(logrus.Fields{
+				"api_key":     apiKey,
+				"api_name":    newApiInfo.Name,
+				"path_diff":   diff.PathDiff,
+				"method_diff": diff.MethodDiff,
+				"body_diff":   diff.RequestBodyDiff,
+				"params_diff": diff.ParametersDiff,
+				"resp_diff":   diff.ResponsesDiff,
+				"auth_diff":   diff.AuthDiff,
+				"meta_diff":   diff.MetadataDiff,
+			}).Info("检测到API变更")
+
+			changeType = apifox.ActionUpdated
+			outcome = SyncOutcomeUpdated
+		}
+	} else {
+		// 这是一个新API
+		s.logger.WithField("api_name", newApiInfo.Name).Info("发现新API")
+
+		if recordNew {
+			changeType = apifox.ActionCreated
+		}
+		outcome = SyncOutcomeNew
+	}
+
+	// 无论是否有变更，都保存最新信息
+	if err := s.storage.SaveApi(newApiInfo); err != nil {
+		s.logger.WithError(err).WithField("api_key", apiKey).Error("保存API信息失败")
+		return SyncOutcomeError, err
+	}
+
+	if changeType != "" {
+		s.RecordChange(diff, apiDetailResp.Data, changeType, apifox.SourceSync)
+	}
+
+	return outcome, nil
 }
 
-// InitializeApiList 初始化API列表
-func (s *ApiService) InitializeApiList() (int, int, []string, error) {
+// InitializeApiList 初始化API列表，progress 可为 nil，ctx 取消时中止并返回 ctx 的错误
+func (s *ApiService) InitializeApiList(ctx context.Context, progress *Progress) (int, int, []string, error) {
+	s.workMutex.Lock()
+	defer s.workMutex.Unlock()
+
+	if progress == nil {
+		progress = &Progress{}
+	}
+	s.beginInit(progress)
+
 	s.logger.Info("开始初始化 API 列表")
 
 	// 获取 API 树形列表
-	resp, err := s.apifox.GetApiTreeList()
+	resp, err := s.apifox.GetApiTreeList(ctx)
 	if err != nil {
 		s.logger.WithError(err).Error("无法获取 API 树形列表")
+		s.setInitResult(err)
 		return 0, 0, nil, err
 	}
 
 	if resp == nil || !resp.Success {
 		s.logger.Warn("API 树形列表返回非成功状态")
-		return 0, 0, nil, fmt.Errorf("API 树形列表请求未成功")
+		err := fmt.Errorf("API 树形列表请求未成功")
+		s.setInitResult(err)
+		return 0, 0, nil, err
 	}
 
-	// 提取所有 API 项
-	apiItems := s.ExtractApiItems(resp.Data)
-	s.logger.WithField("api_count", len(apiItems)).Info("已提取 API 项")
-
-	// 过滤出类型为 apiDetail 的项
-	var validApiItems []ApiItem
-	for _, item := range apiItems {
-		if item.Type == "apiDetail" && item.Key != "" {
-			validApiItems = append(validApiItems, item)
-		}
-	}
+	// 提取所有 API 项，包括各级目录下的
+	validApiItems := ExtractApiItems(&resp.Data)
 	s.logger.WithField("valid_api_count", len(validApiItems)).Info("有效 API 数量")
 
 	// 初始化计数器和通道
@@ -271,6 +567,8 @@ func (s *ApiService) InitializeApiList() (int, int, []string, error) {
 	var wg sync.WaitGroup
 	wg.Add(len(validApiItems))
 
+	progress.SetTotal(len(validApiItems))
+
 	// 限制并发数
 	maxConcurrency := 5
 	sem := make(chan struct{}, maxConcurrency)
@@ -279,15 +577,16 @@ func (s *ApiService) InitializeApiList() (int, int, []string, error) {
 
 	// 并发处理每个 API 项
 	for _, item := range validApiItems {
-		// 获取 API 的基本信息（从API树形列表中提取）
-		apiBasicInfo := findApiBasicInfoFromTreeItem(item, resp.Data)
-
 		// 启动 goroutine 获取 API 详情
-		go func(item ApiItem, basicInfo *apifox.ApiBasic) {
+		go func(item ApiItem) {
 			defer wg.Done()
 
-			// 占用并发槽
-			sem <- struct{}{}
+			// 占用并发槽，已取消时不再发起请求
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
 			defer func() { <-sem }()
 
 			s.logger.WithFields(logrus.Fields{
@@ -296,7 +595,10 @@ func (s *ApiService) InitializeApiList() (int, int, []string, error) {
 			}).Debug("处理 API")
 
 			// 获取 API 详情
-			apiDetails, err := s.apifox.GetApiDetail(item.Key)
+			apiDetails, err := s.apifox.GetApiDetail(ctx, item.Key)
+			if ctx.Err() != nil {
+				return
+			}
 
 			// 创建 API 信息对象，准备存储
 			var apiInfo apifox.StoredApiInfo
@@ -304,11 +606,11 @@ func (s *ApiService) InitializeApiList() (int, int, []string, error) {
 			apiInfo.Name = item.Name
 			apiInfo.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
 
-			// 如果有基本信息，先设置方法和路径
-			if basicInfo != nil {
-				apiInfo.ApiID = basicInfo.ID
-				apiInfo.Method = strings.ToLower(basicInfo.Method)
-				apiInfo.ApiPath = basicInfo.Path
+			// 如果有基本信息（从API树形列表中提取），先设置方法和路径
+			if item.Basic != nil {
+				apiInfo.ApiID = item.Basic.ID
+				apiInfo.Method = strings.ToLower(item.Basic.Method)
+				apiInfo.ApiPath = item.Basic.Path
 			}
 
 			// 如果成功获取到详情且不为空对象，使用详情信息
@@ -317,10 +619,14 @@ func (s *ApiService) InitializeApiList() (int, int, []string, error) {
 				apiInfo.Method = strings.ToLower(apiDetails.Data.Method)
 				apiInfo.ApiPath = apiDetails.Data.Path
 				apiInfo.Detail = apiDetails.Data
+				apiInfo.TreeFingerprint = item.Fingerprint
 
 				mutex.Lock()
 				successCount++
 				mutex.Unlock()
+				progress.Add(true)
+				s.logInitProgress(progress)
+				s.retries.remove(item.Key)
 
 				s.logger.WithFields(logrus.Fields{
 					"api_name": item.Name,
@@ -333,11 +639,24 @@ func (s *ApiService) InitializeApiList() (int, int, []string, error) {
 				failureCount++
 				failedApis = append(failedApis, item.Name)
 				mutex.Unlock()
+				progress.Add(false)
+				s.logInitProgress(progress)
 
 				if err != nil {
 					s.logger.WithError(err).WithField("api_name", item.Name).Warn("获取 API 详情失败，使用基本信息")
 				} else {
 					s.logger.WithField("api_name", item.Name).Warn("API 详情为空对象，使用基本信息")
+					err = fmt.Errorf("API详情无效: %s", item.Key)
+				}
+
+				// 仅有基本信息的基线不参与比较，等待重试补全
+				apiInfo.Incomplete = true
+				s.scheduleRetry(item.Key, item.Name, err)
+
+				// 已有完整基线时（如重新初始化）保留原基线
+				if existing, ok := s.storage.GetApi(item.Key); ok && !existing.Incomplete {
+					s.logger.WithField("api_name", item.Name).Info("保留已有的完整基线")
+					return
 				}
 			}
 
@@ -355,113 +674,109 @@ func (s *ApiService) InitializeApiList() (int, int, []string, error) {
 			} else {
 				s.logger.WithField("api_name", item.Name).Warn("API 方法或路径为空，无法保存")
 			}
-		}(item, apiBasicInfo)
+		}(item)
 	}
 
 	// 等待所有 goroutine 完成
 	wg.Wait()
 
+	if ctx.Err() != nil {
+		s.logger.Info("API 列表初始化已取消")
+		s.setInitResult(ctx.Err())
+		return successCount, failureCount, failedApis, ctx.Err()
+	}
+
 	s.logger.WithFields(logrus.Fields{
 		"success_count": successCount,
 		"failure_count": failureCount,
 	}).Info("API 列表初始化完成")
 
-	return successCount, failureCount, failedApis, nil
-}
+	s.setInitResult(nil)
 
-// isEmptyApiDetail 检查 API 详情是否为空对象
-func isEmptyApiDetail(detail apifox.ApiDetail) bool {
-	return detail.ID == 0 && detail.Name == "" && detail.Path == "" && detail.Method == ""
+	return successCount, failureCount, failedApis, nil
 }
 
-// findApiBasicInfoFromTreeItem 从 API 树形列表中查找 API 的基本信息
-func findApiBasicInfoFromTreeItem(item ApiItem, treeData interface{}) *apifox.ApiBasic {
-	// 从数组中查找
-	if data, ok := treeData.([]interface{}); ok {
-		for _, d := range data {
-			if m, ok := d.(map[string]interface{}); ok {
-				// 检查是否匹配当前项
-				if keyStr, ok := m["key"].(string); ok && keyStr == item.Key {
-					// 尝试获取 api 字段
-					if apiData, ok := m["api"].(map[string]interface{}); ok {
-						basic := &apifox.ApiBasic{}
-
-						// 提取基本字段
-						if id, ok := apiData["id"].(float64); ok {
-							basic.ID = int(id)
-						}
-						if name, ok := apiData["name"].(string); ok {
-							basic.Name = name
-						}
-						if method, ok := apiData["method"].(string); ok {
-							basic.Method = method
-						}
-						if path, ok := apiData["path"].(string); ok {
-							basic.Path = path
-						}
-
-						return basic
-					}
-				}
+// ClearStore 清空存储中的 API 信息，等待进行中的初始化或同步结束后执行
+func (s *ApiService) ClearStore() {
+	s.workMutex.Lock()
+	defer s.workMutex.Unlock()
 
-				// 递归检查 children 字段
-				if children, ok := m["children"]; ok && children != nil {
-					if result := findApiBasicInfoFromTreeItem(item, children); result != nil {
-						return result
-					}
-				}
-			}
-		}
-	}
+	s.storage.ClearAll()
+	s.retries.clear()
+	s.logger.Info("已清空 API 存储")
+}
 
-	return nil
+// RebuildStore 清空存储后重新初始化 API 列表
+func (s *ApiService) RebuildStore(ctx context.Context, progress *Progress) (int, int, []string, error) {
+	s.ClearStore()
+	return s.InitializeApiList(ctx, progress)
 }
 
-// ExtractApiItems 从数据中提取 API 项
-func (s *ApiService) ExtractApiItems(data interface{}) []ApiItem {
-	var result []ApiItem
+// RecordChange 记录一次 API 变更到变更历史，需在最新快照保存后调用
+func (s *ApiService) RecordChange(diff *apifox.ApiDiff, detail apifox.ApiDetail, changeType, source string) apifox.ChangeRecord {
+	record := apifox.ChangeRecord{
+		ApiKey:        fmt.Sprintf("apiDetail.%d", detail.ID),
+		ApiID:         detail.ID,
+		Name:          detail.Name,
+		Method:        strings.ToLower(detail.Method),
+		Path:          detail.Path,
+		FolderID:      detail.FolderID,
+		ResponsibleID: detail.ResponsibleID,
+		ChangeType:    changeType,
+		Severity:      apifox.ClassifySeverity(diff, changeType),
+		Source:        source,
+		Diff:          diff,
+	}
 
-	// 处理数组类型数据
-	if items, ok := data.([]interface{}); ok {
-		for _, item := range items {
-			result = append(result, s.ExtractApiItems(item)...)
+	if diff != nil {
+		record.ModifierName = diff.ModifierName
+		record.ModifiedTime = diff.ModifiedTime
+
+		// 仅由基本信息建立的快照没有详情，此时以差异中的信息为准
+		if detail.ID == 0 && diff.ApiKey != "" {
+			record.ApiKey = diff.ApiKey
+			record.ApiID = diff.ApiID
+			record.Name = diff.Name
+			record.Method = strings.ToLower(diff.OldMethod)
+			record.Path = diff.OldPath
 		}
-		return result
 	}
 
-	// 处理映射类型数据
-	if item, ok := data.(map[string]interface{}); ok {
-		// 获取关键字段
-		var apiItem ApiItem
-
-		if key, ok := item["key"].(string); ok {
-			apiItem.Key = key
-		}
-		if typ, ok := item["type"].(string); ok {
-			apiItem.Type = typ
-		}
-		if name, ok := item["name"].(string); ok {
-			apiItem.Name = name
+	// 关联到对应的快照版本，便于查看前后对比
+	if versions, ok := s.storage.GetApiVersions(record.ApiKey); ok && len(versions) > 0 {
+		record.ToVersion = versions[len(versions)-1].Version
+		if changeType == apifox.ActionUpdated && len(versions) > 1 {
+			record.FromVersion = versions[len(versions)-2].Version
 		}
+	}
 
-		// 如果是有效的API项，添加到结果中
-		if apiItem.Key != "" && apiItem.Type != "" {
-			result = append(result, apiItem)
-		}
+	return s.storage.AddChange(record)
+}
 
-		// 递归处理子项
-		if children, ok := item["children"]; ok && children != nil {
-			childResults := s.ExtractApiItems(children)
-			result = append(result, childResults...)
-		}
-	}
+// isEmptyApiDetail 检查 API 详情是否为空对象
+func isEmptyApiDetail(detail apifox.ApiDetail) bool {
+	return detail.ID == 0 && detail.Name == "" && detail.Path == "" && detail.Method == ""
+}
 
-	return result
+// ExtractApiItems 从树形列表中提取所有 API 项，包括各级目录下的
+func ExtractApiItems(tree *apifox.ApiTree) []ApiItem {
+	apis := tree.Apis()
+	items := make([]ApiItem, 0, len(apis))
+	for _, node := range apis {
+		items = append(items, ApiItem{
+			Key:         node.Key,
+			Name:        node.Name,
+			Basic:       node.Api,
+			Fingerprint: node.Fingerprint(),
+		})
+	}
+	return items
 }
 
 // ApiItem 表示一个 API 项
 type ApiItem struct {
-	Key  string
-	Type string
-	Name string
+	Key         string
+	Name        string
+	Basic       *apifox.ApiBasic // 树形列表中的基本信息，可能为 nil
+	Fingerprint string           // 树形列表元数据指纹，用于增量同步
 }
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxScheduleSearch 查找下次执行时间的上限，覆盖闰年 2 月 29 日这类最稀疏的表达式
+const maxScheduleSearch = 5 * 366 * 24 * time.Hour
+
+// Schedule 解析后的 cron 表达式，格式为 "分 时 日 月 周"，按本地时区计算
+type Schedule struct {
+	spec    string
+	minutes [60]bool
+	hours   [24]bool
+	days    [32]bool // 1-31
+	months  [13]bool // 1-12
+	weekday [7]bool  // 0-6，0 为周日，7 同样表示周日
+	// 日和周都有限制时，按 cron 的惯例满足其一即可
+	daysRestricted    bool
+	weekdayRestricted bool
+}
+
+// ParseSchedule 解析 cron 表达式，每个字段支持 "*"、数字、范围 "1-5"、列表 "1,3,5" 和步长 "*/15"、"9-18/3"
+func ParseSchedule(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron 表达式需要 5 个字段（分 时 日 月 周）: %q", spec)
+	}
+
+	s := &Schedule{spec: spec}
+	var err error
+	if err = parseCronField(fields[0], 0, 59, s.minutes[:]); err != nil {
+		return nil, fmt.Errorf("分钟字段无效: %w", err)
+	}
+	if err = parseCronField(fields[1], 0, 23, s.hours[:]); err != nil {
+		return nil, fmt.Errorf("小时字段无效: %w", err)
+	}
+	if err = parseCronField(fields[2], 1, 31, s.days[:]); err != nil {
+		return nil, fmt.Errorf("日期字段无效: %w", err)
+	}
+	if err = parseCronField(fields[3], 1, 12, s.months[:]); err != nil {
+		return nil, fmt.Errorf("月份字段无效: %w", err)
+	}
+	var weekday [8]bool
+	if err = parseCronField(fields[4], 0, 7, weekday[:]); err != nil {
+		return nil, fmt.Errorf("星期字段无效: %w", err)
+	}
+	copy(s.weekday[:], weekday[:7])
+	if weekday[7] {
+		s.weekday[0] = true
+	}
+
+	s.daysRestricted = fields[2] != "*"
+	s.weekdayRestricted = fields[4] != "*"
+	return s, nil
+}
+
+// parseCronField 解析单个字段，将匹配的取值在 set 中标记为 true
+func parseCronField(field string, min, max int, set []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("步长无效: %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return fmt.Errorf("范围无效: %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return fmt.Errorf("取值无效: %q", part)
+			}
+			lo, hi = n, n
+			// "5/10" 表示从 5 开始每 10 个
+			if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return fmt.Errorf("取值超出范围 %d-%d: %q", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+// String 返回原始表达式
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next 返回晚于 t 的下一个执行时间（精确到分钟），找不到时返回零值
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxScheduleSearch)
+
+	for next.Before(limit) {
+		if !s.months[next.Month()] {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.dayMatches(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.hours[next.Hour()] {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.minutes[next.Minute()] {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return time.Time{}
+}
+
+// dayMatches 检查日期和星期，两者都有限制时满足其一即可
+func (s *Schedule) dayMatches(t time.Time) bool {
+	day, weekday := s.days[t.Day()], s.weekday[t.Weekday()]
+	if s.daysRestricted && s.weekdayRestricted {
+		return day || weekday
+	}
+	return day && weekday
+}
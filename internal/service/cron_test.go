@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// TestScheduleNext 下次执行时间严格晚于给定时间，日期和星期都有限制时满足其一即可
+func TestScheduleNext(t *testing.T) {
+	// 2024-05-01 为周三
+	from := time.Date(2024, 5, 1, 10, 30, 15, 0, time.Local)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 1, 10, 31, 0, 0, time.Local)},
+		{"*/15 * * * *", time.Date(2024, 5, 1, 10, 45, 0, 0, time.Local)},
+		{"0 9 * * *", time.Date(2024, 5, 2, 9, 0, 0, 0, time.Local)},
+		{"0 9-18/3 * * *", time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)},
+		{"0 9 * * 1", time.Date(2024, 5, 6, 9, 0, 0, 0, time.Local)},
+		{"0 9 * * 7", time.Date(2024, 5, 5, 9, 0, 0, 0, time.Local)},
+		{"0 9 * * 1-5", time.Date(2024, 5, 2, 9, 0, 0, 0, time.Local)},
+		{"0 0 15 * 1", time.Date(2024, 5, 6, 0, 0, 0, 0, time.Local)},
+		{"30 8 1 6 *", time.Date(2024, 6, 1, 8, 30, 0, 0, time.Local)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", tt.spec, err)
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+// TestParseScheduleInvalid 字段数量或取值无效时返回错误
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{"", "0 9 * *", "60 * * * *", "0 24 * * *", "0 9 0 * *", "0 9 * 13 *", "0 9 * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) 应返回错误", spec)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xhy/api-pulse/internal/apifox"
+	"github.com/xhy/api-pulse/internal/storage"
+)
+
+// 摘要中列出的修改者数量上限
+const digestTopModifiers = 5
+
+// digestSyncModifier 同步检测到的变更没有修改者，摘要中统一显示为该名称
+const digestSyncModifier = "同步检测"
+
+// DigestNotifier 发送变更摘要
+type DigestNotifier interface {
+	SendDigestNotification(digest apifox.Digest) error
+}
+
+// DigestService 按 cron 表达式定时汇总变更历史并发送摘要
+type DigestService struct {
+	logger        *logrus.Logger
+	storage       *storage.ApiStore
+	notifier      DigestNotifier
+	schedule      *Schedule // 为 nil 时不定时发送，只提供预览
+	projectID     string
+	responsibleID int
+	sendEmpty     bool
+
+	mutex   sync.Mutex
+	lastRun time.Time // 下一份摘要的起始时间
+	now     func() time.Time
+}
+
+// NewDigestService 创建变更摘要服务，spec 为空时不定时发送，cron 表达式无效时返回错误
+func NewDigestService(logger *logrus.Logger, store *storage.ApiStore, notifier DigestNotifier, spec, projectID string, responsibleID int, sendEmpty bool) (*DigestService, error) {
+	var schedule *Schedule
+	if spec != "" {
+		var err error
+		if schedule, err = ParseSchedule(spec); err != nil {
+			return nil, err
+		}
+	}
+
+	return &DigestService{
+		logger:        logger,
+		storage:       store,
+		notifier:      notifier,
+		schedule:      schedule,
+		projectID:     projectID,
+		responsibleID: responsibleID,
+		sendEmpty:     sendEmpty,
+		lastRun:       time.Now().Truncate(time.Second), // 变更历史的时间精确到秒
+		now:           time.Now,
+	}, nil
+}
+
+// Schedule 返回定时发送的 cron 表达式，未配置时为 nil
+func (s *DigestService) Schedule() *Schedule {
+	return s.schedule
+}
+
+// Build 汇总 [since, until] 内的变更历史，responsibleID 为 0 时汇总整个项目
+func (s *DigestService) Build(since, until time.Time, responsibleID int) apifox.Digest {
+	filter := storage.ChangeFilter{Since: since, Until: until}
+	if responsibleID != 0 {
+		filter.ResponsibleID = &responsibleID
+	}
+	changes := s.storage.ListChanges(filter)
+
+	digest := apifox.Digest{
+		ProjectID:     s.projectID,
+		ResponsibleID: responsibleID,
+		Since:         since.Format("2006-01-02 15:04:05"),
+		Until:         until.Format("2006-01-02 15:04:05"),
+		Total:         len(changes),
+		ByType:        make(map[string]int),
+		BySeverity:    make(map[string]int),
+		Apis:          make([]apifox.DigestApi, 0),
+		TopModifiers:  make([]apifox.DigestModifier, 0),
+	}
+
+	apis := make(map[string]*apifox.DigestApi)
+	modifiers := make(map[string]int)
+	// 变更历史从新到旧排列，倒序遍历使接口信息取最新一次变更、变更类型按发生顺序排列
+	for i := len(changes) - 1; i >= 0; i-- {
+		record := changes[i]
+		digest.ByType[record.ChangeType]++
+		digest.BySeverity[record.Severity]++
+
+		modifier := record.ModifierName
+		if modifier == "" {
+			modifier = digestSyncModifier
+		}
+		modifiers[modifier]++
+
+		api, ok := apis[record.ApiKey]
+		if !ok {
+			api = &apifox.DigestApi{ApiKey: record.ApiKey}
+			apis[record.ApiKey] = api
+		}
+		api.Name, api.Method, api.Path = record.Name, record.Method, record.Path
+		api.Changes++
+		if severityRank(record.Severity) > severityRank(api.Severity) {
+			api.Severity = record.Severity
+		}
+		if !containsString(api.ChangeTypes, record.ChangeType) {
+			api.ChangeTypes = append(api.ChangeTypes, record.ChangeType)
+		}
+	}
+
+	for _, api := range apis {
+		digest.Apis = append(digest.Apis, *api)
+	}
+	// 严重程度高的在前，其次按变更次数
+	sort.Slice(digest.Apis, func(i, j int) bool {
+		a, b := digest.Apis[i], digest.Apis[j]
+		if severityRank(a.Severity) != severityRank(b.Severity) {
+			return severityRank(a.Severity) > severityRank(b.Severity)
+		}
+		if a.Changes != b.Changes {
+			return a.Changes > b.Changes
+		}
+		return a.ApiKey < b.ApiKey
+	})
+
+	for name, count := range modifiers {
+		digest.TopModifiers = append(digest.TopModifiers, apifox.DigestModifier{Name: name, Changes: count})
+	}
+	sort.Slice(digest.TopModifiers, func(i, j int) bool {
+		a, b := digest.TopModifiers[i], digest.TopModifiers[j]
+		if a.Changes != b.Changes {
+			return a.Changes > b.Changes
+		}
+		return a.Name < b.Name
+	})
+	if len(digest.TopModifiers) > digestTopModifiers {
+		digest.TopModifiers = digest.TopModifiers[:digestTopModifiers]
+	}
+
+	return digest
+}
+
+// Preview 返回下一份摘要截至当前的内容，以及下次定时发送的时间（未配置定时发送时为零值）；
+// responsibleID 为 nil 时使用配置的负责人
+func (s *DigestService) Preview(responsibleID *int) (apifox.Digest, time.Time) {
+	s.mutex.Lock()
+	since := s.lastRun
+	s.mutex.Unlock()
+
+	now := s.now()
+	id := s.responsibleID
+	if responsibleID != nil {
+		id = *responsibleID
+	}
+
+	var next time.Time
+	if s.schedule != nil {
+		next = s.schedule.Next(now)
+	}
+	return s.Build(since, now, id), next
+}
+
+// SendDigest 汇总上次发送以来的变更并发送，没有变更且未开启 sendEmpty 时跳过
+func (s *DigestService) SendDigest() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	until := s.now()
+	// 变更历史的时间精确到秒，下一期从下一秒开始，避免同一条变更出现在两份摘要中
+	nextRun := until.Truncate(time.Second).Add(time.Second)
+
+	digest := s.Build(s.lastRun, until, s.responsibleID)
+	if digest.Total == 0 && !s.sendEmpty {
+		s.logger.WithField("since", digest.Since).Info("期间没有 API 变更，跳过变更摘要")
+		s.lastRun = nextRun
+		return nil
+	}
+
+	if err := s.notifier.SendDigestNotification(digest); err != nil {
+		// 发送失败时保留起始时间，下次发送的摘要包含本期变更
+		return fmt.Errorf("发送变更摘要失败: %w", err)
+	}
+
+	s.lastRun = nextRun
+	s.logger.WithFields(logrus.Fields{
+		"total": digest.Total,
+		"apis":  len(digest.Apis),
+	}).Info("已发送 API 变更摘要")
+	return nil
+}
+
+// Start 按 cron 表达式定时发送摘要，直到 ctx 取消；未配置定时发送时直接返回
+func (s *DigestService) Start(ctx context.Context) {
+	if s.schedule == nil {
+		return
+	}
+
+	go func() {
+		for {
+			next := s.schedule.Next(s.now())
+			if next.IsZero() {
+				s.logger.WithField("schedule", s.schedule.String()).Warn("cron 表达式没有可执行的时间，停止变更摘要任务")
+				return
+			}
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+				if err := s.SendDigest(); err != nil {
+					s.logger.WithError(err).Error("发送 API 变更摘要失败")
+				}
+			case <-ctx.Done():
+				timer.Stop()
+				s.logger.Info("停止API变更摘要任务")
+				return
+			}
+		}
+	}()
+
+	s.logger.WithField("schedule", s.schedule.String()).Info("已启动API变更摘要定时任务")
+}
+
+// severityRank 严重程度的排序权重，越大越严重
+func severityRank(severity string) int {
+	switch severity {
+	case apifox.SeverityHigh:
+		return 3
+	case apifox.SeverityMedium:
+		return 2
+	case apifox.SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// containsString 检查字符串切片中是否包含指定值
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 后台任务状态
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// maxJobs 最多保留的任务记录数
+const maxJobs = 100
+
+// Job 后台任务及其进度
+type Job struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Status     string    `json:"status"`
+	Total      int64     `json:"total"`
+	Done       int64     `json:"done"`
+	Failed     int64     `json:"failed"`
+	Message    string    `json:"message,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Progress 任务进度计数器，nil 时所有操作为空操作
+type Progress struct {
+	total  atomic.Int64
+	done   atomic.Int64
+	failed atomic.Int64
+}
+
+// SetTotal 设置需要处理的总数
+func (p *Progress) SetTotal(total int) {
+	if p == nil {
+		return
+	}
+	p.total.Store(int64(total))
+}
+
+// Add 记录一项处理结果
+func (p *Progress) Add(ok bool) {
+	if p == nil {
+		return
+	}
+	if ok {
+		p.done.Add(1)
+	} else {
+		p.failed.Add(1)
+	}
+}
+
+// Counts 返回总数、成功数与失败数
+func (p *Progress) Counts() (total, done, failed int64) {
+	if p == nil {
+		return 0, 0, 0
+	}
+	return p.total.Load(), p.done.Load(), p.failed.Load()
+}
+
+// JobFunc 任务执行函数，返回的 message 会记录到任务上
+type JobFunc func(ctx context.Context, progress *Progress) (message string, err error)
+
+// JobManager 管理后台任务
+type JobManager struct {
+	ctx    context.Context
+	logger *logrus.Logger
+	mutex  sync.RWMutex
+	jobs   map[string]*Job
+	order  []string
+	nextID int64
+
+	progress map[string]*Progress
+}
+
+// NewJobManager 创建任务管理器，ctx 取消时所有进行中的任务随之取消
+func NewJobManager(ctx context.Context, logger *logrus.Logger) *JobManager {
+	return &JobManager{
+		ctx:      ctx,
+		logger:   logger,
+		jobs:     make(map[string]*Job),
+		progress: make(map[string]*Progress),
+	}
+}
+
+// Start 在后台执行任务，立即返回任务快照
+func (m *JobManager) Start(jobType string, fn JobFunc) Job {
+	m.mutex.Lock()
+	m.nextID++
+	job := &Job{
+		ID:        fmt.Sprintf("job-%d", m.nextID),
+		Type:      jobType,
+		Status:    JobPending,
+		CreatedAt: time.Now(),
+	}
+	progress := &Progress{}
+	m.jobs[job.ID] = job
+	m.progress[job.ID] = progress
+	m.order = append(m.order, job.ID)
+
+	// 超出上限时淘汰最早的已结束任务
+	if len(m.order) > maxJobs {
+		for i, id := range m.order {
+			if status := m.jobs[id].Status; status == JobSucceeded || status == JobFailed {
+				delete(m.jobs, id)
+				delete(m.progress, id)
+				m.order = append(m.order[:i], m.order[i+1:]...)
+				break
+			}
+		}
+	}
+	snapshot := *job
+	m.mutex.Unlock()
+
+	m.logger.WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"job_type": jobType,
+	}).Info("创建后台任务")
+
+	go m.run(job.ID, progress, fn)
+
+	return snapshot
+}
+
+// run 执行任务并记录结果
+func (m *JobManager) run(id string, progress *Progress, fn JobFunc) {
+	m.update(id, func(job *Job) {
+		job.Status = JobRunning
+		job.StartedAt = time.Now()
+	})
+
+	message, err := func() (message string, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("任务异常退出: %v", r)
+			}
+		}()
+		return fn(m.ctx, progress)
+	}()
+
+	m.update(id, func(job *Job) {
+		job.Message = message
+		job.FinishedAt = time.Now()
+		if err != nil {
+			job.Status = JobFailed
+			job.Error = err.Error()
+		} else {
+			job.Status = JobSucceeded
+		}
+	})
+
+	entry := m.logger.WithField("job_id", id)
+	if err != nil {
+		entry.WithError(err).Error("后台任务失败")
+	} else {
+		entry.Info("后台任务完成")
+	}
+}
+
+// update 在锁内修改任务
+func (m *JobManager) update(id string, fn func(job *Job)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if job, ok := m.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// Get 获取任务快照
+func (m *JobManager) Get(id string) (Job, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return m.snapshot(job), true
+}
+
+// List 返回所有任务快照，最新的在前
+func (m *JobManager) List() []Job {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	jobs := make([]Job, 0, len(m.order))
+	for i := len(m.order) - 1; i >= 0; i-- {
+		jobs = append(jobs, m.snapshot(m.jobs[m.order[i]]))
+	}
+	return jobs
+}
+
+// snapshot 合并进度计数，调用方需持有锁
+func (m *JobManager) snapshot(job *Job) Job {
+	snapshot := *job
+	if progress, ok := m.progress[job.ID]; ok {
+		snapshot.Total, snapshot.Done, snapshot.Failed = progress.Counts()
+	}
+	return snapshot
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 重试退避参数
+const (
+	retryBaseDelay     = time.Minute
+	retryMaxDelay      = 30 * time.Minute
+	retryCheckInterval = 30 * time.Second
+)
+
+// RetryEntry 等待重新获取详情的 API
+type RetryEntry struct {
+	ApiKey        string    `json:"api_key"`
+	Name          string    `json:"name,omitempty"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	FirstFailedAt time.Time `json:"first_failed_at"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
+
+// retryQueue 详情获取失败的 API 重试队列，按指数退避安排下次重试
+type retryQueue struct {
+	mutex   sync.Mutex
+	entries map[string]*RetryEntry
+}
+
+// newRetryQueue 创建重试队列
+func newRetryQueue() *retryQueue {
+	return &retryQueue{entries: make(map[string]*RetryEntry)}
+}
+
+// retryDelay 第 attempts 次失败后的等待时间，从 1 分钟开始翻倍，最长 30 分钟
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// schedule 记录一次失败并安排下次重试
+func (q *retryQueue) schedule(apiKey, name string, err error) RetryEntry {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := time.Now()
+	entry, ok := q.entries[apiKey]
+	if !ok {
+		entry = &RetryEntry{ApiKey: apiKey, FirstFailedAt: now}
+		q.entries[apiKey] = entry
+	}
+	if name != "" {
+		entry.Name = name
+	}
+	entry.Attempts++
+	entry.LastError = ""
+	if err != nil {
+		entry.LastError = err.Error()
+	}
+	entry.NextAttemptAt = now.Add(retryDelay(entry.Attempts))
+
+	return *entry
+}
+
+// remove 移出重试队列，返回是否存在
+func (q *retryQueue) remove(apiKey string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	_, ok := q.entries[apiKey]
+	delete(q.entries, apiKey)
+	return ok
+}
+
+// has 是否在重试队列中
+func (q *retryQueue) has(apiKey string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	_, ok := q.entries[apiKey]
+	return ok
+}
+
+// clear 清空重试队列
+func (q *retryQueue) clear() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.entries = make(map[string]*RetryEntry)
+}
+
+// due 返回已到重试时间的 API Key
+func (q *retryQueue) due(now time.Time) []string {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var keys []string
+	for key, entry := range q.entries {
+		if !entry.NextAttemptAt.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// list 返回所有条目，按下次重试时间排序
+func (q *retryQueue) list() []RetryEntry {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	entries := make([]RetryEntry, 0, len(q.entries))
+	for _, entry := range q.entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].NextAttemptAt.Before(entries[j].NextAttemptAt)
+	})
+	return entries
+}
+
+// RetryQueue 返回等待重试的 API
+func (s *ApiService) RetryQueue() []RetryEntry {
+	return s.retries.list()
+}
+
+// CancelRetry 将 API 移出重试队列，用于 API 已被删除的情况
+func (s *ApiService) CancelRetry(apiKey string) {
+	s.retries.remove(apiKey)
+}
+
+// scheduleRetry 将详情获取失败的 API 加入重试队列
+func (s *ApiService) scheduleRetry(apiKey, name string, err error) {
+	entry := s.retries.schedule(apiKey, name, err)
+	s.logger.WithFields(logrus.Fields{
+		"api_key":         apiKey,
+		"attempts":        entry.Attempts,
+		"next_attempt_at": entry.NextAttemptAt.Format("2006-01-02 15:04:05"),
+	}).Warn("API 详情获取失败，已加入重试队列")
+}
+
+// processRetries 重新获取已到重试时间的 API 详情
+func (s *ApiService) processRetries(ctx context.Context) {
+	keys := s.retries.due(time.Now())
+	if len(keys) == 0 {
+		return
+	}
+
+	s.logger.WithField("count", len(keys)).Info("开始重试获取 API 详情")
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			return
+		}
+		oldApiInfo, exists := s.storage.GetApi(key)
+		// 重试成功的 API 只是补全基线，不记为新增
+		s.syncApi(ctx, key, "", oldApiInfo, exists, false)
+	}
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/xhy/api-pulse/internal/apifox"
+)
+
+// ChangeFilter 变更历史的查询条件，零值字段表示不过滤
+type ChangeFilter struct {
+	ApiKey        string
+	ResponsibleID *int
+	FolderID      *int
+	Severity      string
+	ChangeType    string
+	Since         time.Time
+	Until         time.Time
+	Limit         int
+}
+
+// AddChange 追加一条变更历史，返回带 ID 的记录
+func (s *ApiStore) AddChange(record apifox.ChangeRecord) apifox.ChangeRecord {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.nextChangeID++
+	record.ID = s.nextChangeID
+	if record.RecordedAt == "" {
+		record.RecordedAt = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	s.changes = append(s.changes, record)
+	if len(s.changes) > maxChangeRecords {
+		s.changes = s.changes[len(s.changes)-maxChangeRecords:]
+	}
+
+	return record
+}
+
+// ListChanges 按条件查询变更历史，结果按时间从新到旧排列
+func (s *ApiStore) ListChanges(filter ChangeFilter) []apifox.ChangeRecord {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]apifox.ChangeRecord, 0)
+	for i := len(s.changes) - 1; i >= 0; i-- {
+		record := s.changes[i]
+		if !filter.matches(record) {
+			continue
+		}
+		result = append(result, record)
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+	return result
+}
+
+// GetChange 根据 ID 获取变更记录
+func (s *ApiStore) GetChange(id int64) (apifox.ChangeRecord, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, record := range s.changes {
+		if record.ID == id {
+			return record, true
+		}
+	}
+	return apifox.ChangeRecord{}, false
+}
+
+// matches 检查记录是否满足过滤条件
+func (f ChangeFilter) matches(record apifox.ChangeRecord) bool {
+	if f.ApiKey != "" && record.ApiKey != f.ApiKey {
+		return false
+	}
+	if f.ResponsibleID != nil && record.ResponsibleID != *f.ResponsibleID {
+		return false
+	}
+	if f.FolderID != nil && record.FolderID != *f.FolderID {
+		return false
+	}
+	if f.Severity != "" && record.Severity != f.Severity {
+		return false
+	}
+	if f.ChangeType != "" && record.ChangeType != f.ChangeType {
+		return false
+	}
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		recordedAt, err := time.ParseInLocation("2006-01-02 15:04:05", record.RecordedAt, time.Local)
+		if err != nil {
+			return false
+		}
+		if !f.Since.IsZero() && recordedAt.Before(f.Since) {
+			return false
+		}
+		if !f.Until.IsZero() && recordedAt.After(f.Until) {
+			return false
+		}
+	}
+	return true
+}
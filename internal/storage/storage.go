@@ -1,19 +1,43 @@
 package storage
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/xhy/api-pulse/internal/apifox"
 )
 
+const (
+	// maxUnknownEvents 最多保留的未知 Webhook 事件数量
+	maxUnknownEvents = 100
+	// maxVersionsPerApi 每个 API 最多保留的历史版本数量
+	maxVersionsPerApi = 50
+	// maxChangeRecords 最多保留的变更历史记录数量
+	maxChangeRecords = 2000
+)
+
 // ApiStore API 存储服务 - 纯内存实现
 type ApiStore struct {
-	apisByKey  map[string]apifox.StoredApiInfo // 使用 ApiKey 索引
-	apisByPath map[string]apifox.StoredApiInfo // 使用 ApiPath 索引
-	mutex      sync.RWMutex
-	logger     *logrus.Logger
+	apisByKey     map[string]apifox.StoredApiInfo // 使用 ApiKey 索引
+	apisByPath    map[string]apifox.StoredApiInfo // 使用 ApiPath 索引
+	routes        map[string]map[string]pathRoute // 按小写 HTTP 方法分组的已解析路径模板，内层以 apisByPath 的键索引
+	versions      map[string][]apifox.ApiVersion  // 每个 ApiKey 的历史快照，按版本号递增
+	unknownEvents []apifox.WebhookEventRecord     // 未识别的 Webhook 事件，按接收顺序
+	changes       []apifox.ChangeRecord           // 变更历史，按记录顺序
+	nextChangeID  int64
+	mutex         sync.RWMutex
+	logger        *logrus.Logger
+}
+
+// pathRoute 保存时解析好的路径模板，按路径查找和匹配请求时不再重复解析
+type pathRoute struct {
+	template apifox.PathTemplate
+	shape    string
 }
 
 // NewApiStore 创建新的 API 存储服务
@@ -21,6 +45,8 @@ func NewApiStore(logger *logrus.Logger) *ApiStore {
 	return &ApiStore{
 		apisByKey:  make(map[string]apifox.StoredApiInfo),
 		apisByPath: make(map[string]apifox.StoredApiInfo),
+		routes:     make(map[string]map[string]pathRoute),
+		versions:   make(map[string][]apifox.ApiVersion),
 		logger:     logger,
 	}
 }
@@ -36,7 +62,7 @@ func (s *ApiStore) SaveApi(apiInfo apifox.StoredApiInfo) error {
 		// 如果旧的API路径存在且与新的不同，需要删除旧的路径索引
 		if oldApiInfo.ApiPath != "" && (oldApiInfo.Method != apiInfo.Method || oldApiInfo.ApiPath != apiInfo.ApiPath) {
 			oldPathKey := fmt.Sprintf("%s %s", oldApiInfo.Method, oldApiInfo.ApiPath)
-			delete(s.apisByPath, oldPathKey)
+			s.removePath(oldApiInfo.Method, oldPathKey)
 			s.logger.WithFields(logrus.Fields{
 				"api_key":    apiInfo.ApiKey,
 				"old_path":   oldPathKey,
@@ -49,15 +75,98 @@ func (s *ApiStore) SaveApi(apiInfo apifox.StoredApiInfo) error {
 	// 更新Key索引
 	s.apisByKey[apiInfo.ApiKey] = apiInfo
 
+	// 详情有变化时记录一个新版本，不完整的基线不作为版本
+	if !apiInfo.Incomplete {
+		s.appendVersion(apiInfo)
+	}
+
 	// 如果 ApiPath 不为空，则也按路径索引
 	if apiInfo.ApiPath != "" {
 		pathKey := fmt.Sprintf("%s %s", apiInfo.Method, apiInfo.ApiPath)
 		s.apisByPath[pathKey] = apiInfo
+		s.indexRoute(apiInfo.Method, pathKey, apiInfo.ApiPath)
 	}
 
 	return nil
 }
 
+// indexRoute 解析路径模板并加入路由索引，调用方需持有写锁
+func (s *ApiStore) indexRoute(method, pathKey, path string) {
+	method = strings.ToLower(method)
+	routes, ok := s.routes[method]
+	if !ok {
+		routes = make(map[string]pathRoute)
+		s.routes[method] = routes
+	}
+	template := apifox.ParsePathTemplate(path)
+	routes[pathKey] = pathRoute{template: template, shape: template.Shape()}
+}
+
+// removePath 删除路径索引及对应的路由，调用方需持有写锁
+func (s *ApiStore) removePath(method, pathKey string) {
+	delete(s.apisByPath, pathKey)
+
+	method = strings.ToLower(method)
+	if routes, ok := s.routes[method]; ok {
+		delete(routes, pathKey)
+		if len(routes) == 0 {
+			delete(s.routes, method)
+		}
+	}
+}
+
+// comparableDetailJSON 返回用于判断详情是否变化的 JSON，按差异比较的规则规范化后编码
+func comparableDetailJSON(detail apifox.ApiDetail) []byte {
+	data, _ := json.Marshal(apifox.Normalize(detail))
+	return data
+}
+
+// appendVersion 在详情与最新版本不同时追加历史版本，调用方需持有写锁
+func (s *ApiStore) appendVersion(apiInfo apifox.StoredApiInfo) {
+	history := s.versions[apiInfo.ApiKey]
+
+	newDetailJSON := comparableDetailJSON(apiInfo.Detail)
+	nextVersion := 1
+	if len(history) > 0 {
+		latest := history[len(history)-1]
+		latestDetailJSON := comparableDetailJSON(latest.Detail)
+		if bytes.Equal(latestDetailJSON, newDetailJSON) {
+			return
+		}
+		nextVersion = latest.Version + 1
+	}
+
+	savedAt := apiInfo.UpdatedAt
+	if savedAt == "" {
+		savedAt = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	history = append(history, apifox.ApiVersion{
+		Version: nextVersion,
+		SavedAt: savedAt,
+		Detail:  apiInfo.Detail,
+	})
+	if len(history) > maxVersionsPerApi {
+		history = history[len(history)-maxVersionsPerApi:]
+	}
+	s.versions[apiInfo.ApiKey] = history
+}
+
+// GetApiVersions 获取 API 的历史版本，按版本号从旧到新排列
+func (s *ApiStore) GetApiVersions(apiKey string) ([]apifox.ApiVersion, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	history, exists := s.versions[apiKey]
+	if !exists {
+		return nil, false
+	}
+
+	versions := make([]apifox.ApiVersion, len(history))
+	copy(versions, history)
+	return versions, true
+}
+
 // GetApi 根据 ApiKey 获取 API 信息
 func (s *ApiStore) GetApi(apiKey string) (apifox.StoredApiInfo, bool) {
 	s.mutex.RLock()
@@ -67,14 +176,57 @@ func (s *ApiStore) GetApi(apiKey string) (apifox.StoredApiInfo, bool) {
 	return api, exists
 }
 
-// GetApiByPath 根据 HTTP 方法和路径获取 API 信息
+// GetApiByPath 根据 HTTP 方法和路径模板获取 API 信息，没有完全相同的路径时，
+// 按只有路径参数名不同的模板查找（如 /users/{userId} 可找到 /users/{id}）
 func (s *ApiStore) GetApiByPath(method, path string) (apifox.StoredApiInfo, bool) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
 	pathKey := fmt.Sprintf("%s %s", method, path)
-	api, exists := s.apisByPath[pathKey]
-	return api, exists
+	if api, exists := s.apisByPath[pathKey]; exists {
+		return api, true
+	}
+
+	// 同一结构有多个 API 时按 ApiKey 取较小者，保证结果稳定
+	shape := apifox.ParsePathTemplate(path).Shape()
+	var found apifox.StoredApiInfo
+	for routeKey, route := range s.routes[strings.ToLower(method)] {
+		if route.shape != shape {
+			continue
+		}
+		if api := s.apisByPath[routeKey]; found.ApiKey == "" || api.ApiKey < found.ApiKey {
+			found = api
+		}
+	}
+	return found, found.ApiKey != ""
+}
+
+// MatchApi 像路由器一样将具体的请求路径（如 GET /users/42）匹配到 API，返回路径参数的取值；
+// 多个模板都能匹配时，从左到右第一处不同的段上固定文本优先于路径参数
+func (s *ApiStore) MatchApi(method, path string) (apifox.StoredApiInfo, map[string]string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var (
+		best         apifox.StoredApiInfo
+		bestTemplate apifox.PathTemplate
+		bestParams   map[string]string
+		found        bool
+	)
+	for routeKey, route := range s.routes[strings.ToLower(method)] {
+		template := route.template
+		params, ok := template.Match(path)
+		if !ok {
+			continue
+		}
+		api := s.apisByPath[routeKey]
+		// 同样具体时按 ApiKey 取较小者，保证结果稳定
+		if !found || template.MoreSpecific(bestTemplate) ||
+			(!bestTemplate.MoreSpecific(template) && api.ApiKey < best.ApiKey) {
+			best, bestTemplate, bestParams, found = api, template, params, true
+		}
+	}
+	return best, bestParams, found
 }
 
 // GetAllApis 获取所有 API 信息
@@ -90,6 +242,14 @@ func (s *ApiStore) GetAllApis() map[string]apifox.StoredApiInfo {
 	return apis
 }
 
+// Count 返回存储的 API 数量
+func (s *ApiStore) Count() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.apisByKey)
+}
+
 // GetAllApisByPath 获取所有按路径索引的 API 信息
 func (s *ApiStore) GetAllApisByPath() map[string]apifox.StoredApiInfo {
 	s.mutex.RLock()
@@ -103,11 +263,57 @@ func (s *ApiStore) GetAllApisByPath() map[string]apifox.StoredApiInfo {
 	return apis
 }
 
-// ClearAll 清空所有 API 信息
+// DeleteApi 删除 API 信息及其路径索引
+func (s *ApiStore) DeleteApi(apiKey string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	apiInfo, exists := s.apisByKey[apiKey]
+	if !exists {
+		return false
+	}
+
+	// 历史版本保留，便于查看已删除接口的变更记录
+	delete(s.apisByKey, apiKey)
+	if apiInfo.ApiPath != "" {
+		pathKey := fmt.Sprintf("%s %s", apiInfo.Method, apiInfo.ApiPath)
+		// 路径索引可能已被其他 API 占用，只删除指向自身的索引
+		if indexed, ok := s.apisByPath[pathKey]; ok && indexed.ApiKey == apiKey {
+			s.removePath(apiInfo.Method, pathKey)
+		}
+	}
+
+	return true
+}
+
+// SaveUnknownEvent 保存未识别的 Webhook 事件，超出上限时丢弃最早的记录
+func (s *ApiStore) SaveUnknownEvent(record apifox.WebhookEventRecord) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.unknownEvents = append(s.unknownEvents, record)
+	if len(s.unknownEvents) > maxUnknownEvents {
+		s.unknownEvents = s.unknownEvents[len(s.unknownEvents)-maxUnknownEvents:]
+	}
+}
+
+// GetUnknownEvents 获取已保存的未识别 Webhook 事件
+func (s *ApiStore) GetUnknownEvents() []apifox.WebhookEventRecord {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	events := make([]apifox.WebhookEventRecord, len(s.unknownEvents))
+	copy(events, s.unknownEvents)
+	return events
+}
+
+// ClearAll 清空所有 API 信息，变更历史保留
 func (s *ApiStore) ClearAll() {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	s.apisByKey = make(map[string]apifox.StoredApiInfo)
 	s.apisByPath = make(map[string]apifox.StoredApiInfo)
+	s.routes = make(map[string]map[string]pathRoute)
+	s.versions = make(map[string][]apifox.ApiVersion)
 }